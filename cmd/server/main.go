@@ -20,6 +20,13 @@ func main() {
 		panic(err)
 	}
 
+	// a broken scenes file shouldn't keep the lights off; start without
+	// scenes and leave the file untouched
+	err = ctl.LoadScenes()
+	if err != nil {
+		fmt.Println("failed to load scenes:", err)
+	}
+
 	err = ctl.Start()
 	if err != nil {
 		panic(err)
@@ -12,10 +12,21 @@ import (
 	"ledctl3/internal/client/controller/audio"
 	"ledctl3/internal/client/controller/video"
 	"ledctl3/internal/pkg/event"
+	"ledctl3/pkg/clocksync"
+	"ledctl3/pkg/filter"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// pingInterval is how often the server clock offset is re-estimated.
+	pingInterval = 1 * time.Second
+
+	// clockSamples is the amount of ping measurements the clock offset is
+	// estimated from.
+	clockSamples = 16
+)
+
 type Application struct {
 	DefaultMode controller.Mode
 
@@ -28,8 +39,14 @@ type Application struct {
 	BlackPoint float64
 	Segments   []Segment
 
-	connMux sync.Mutex
-	conn    *websocket.Conn
+	// PlayoutDelay is added to the send time of each frame to get its
+	// presentation time on the server.
+	PlayoutDelay time.Duration
+
+	connMux  sync.Mutex
+	conn     *websocket.Conn
+	writeMux sync.Mutex
+	clock    *clocksync.Estimator
 
 	Displays       video.DisplayRepository
 	DisplayConfigs [][]video.DisplayConfig
@@ -53,10 +70,17 @@ type Application struct {
 type Segment struct {
 	Id   int
 	Leds int
+
+	// AudioFilter and VideoFilter smooth the colors of the segment in each
+	// visualizer.
+	AudioFilter filter.Spec
+	VideoFilter filter.Spec
 }
 
 func New(opts ...Option) (*Application, error) {
-	a := &Application{}
+	a := &Application{
+		clock: clocksync.New(clockSamples),
+	}
 
 	for _, opt := range opts {
 		err := opt(a)
@@ -82,8 +106,9 @@ func New(opts ...Option) (*Application, error) {
 	segs := []audio.Segment{}
 	for _, seg := range a.Segments {
 		segs = append(segs, audio.Segment{
-			Id:   seg.Id,
-			Leds: seg.Leds,
+			Id:     seg.Id,
+			Leds:   seg.Leds,
+			Filter: seg.AudioFilter,
 		})
 	}
 
@@ -121,13 +146,15 @@ func New(opts ...Option) (*Application, error) {
 			//	fmt.Printf("-> %s\n", e)
 			//}
 
+			a.timestamp(events)
+
 			b, err := json.Marshal(events)
 			if err != nil {
 				fmt.Println(err)
 				return
 			}
 
-			err = conn.WriteMessage(websocket.TextMessage, b)
+			err = a.send(conn, b)
 			if err != nil {
 				a.connMux.Lock()
 				a.conn = nil
@@ -171,10 +198,17 @@ func (a *Application) Start() error {
 
 					fmt.Println("connected")
 
+					a.clock.Reset()
+
 					a.connMux.Lock()
 					a.conn = conn
 					a.connMux.Unlock()
 
+					pingCtx, cancelPing := context.WithCancel(context.Background())
+					defer cancelPing()
+
+					go a.ping(pingCtx, conn)
+
 					for {
 						typ, b, err := conn.ReadMessage()
 						if err != nil {
@@ -263,6 +297,12 @@ func (a *Application) Stop() error {
 	return a.ctl.SetMode(controller.Reset)
 }
 
+// SetVideoProcessing changes the color processing of a display while the
+// client is running.
+func (a *Application) SetVideoProcessing(display int, p video.Processing) error {
+	return a.ctl.SetVideoProcessing(display, p)
+}
+
 func (a *Application) Handle(t event.Type, b []byte) {
 	switch t {
 	case event.Connected:
@@ -274,4 +314,74 @@ func (a *Application) ProcessEvents(events ...event.Event) {
 	//for _, e := range events {
 	//	fmt.Printf("<- %s\n", e)
 	//}
+
+	now := time.Now()
+
+	for _, e := range events {
+		switch e := e.(type) {
+		case event.PongEvent:
+			a.clock.Add(
+				time.Unix(0, e.Origin),
+				time.Unix(0, e.Receive),
+				time.Unix(0, e.Transmit),
+				now,
+			)
+		}
+	}
+}
+
+// send writes a message to the connection. Writes are serialized as the
+// websocket connection supports only one concurrent writer.
+func (a *Application) send(conn *websocket.Conn, b []byte) error {
+	a.writeMux.Lock()
+	defer a.writeMux.Unlock()
+
+	return conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// ping periodically measures the clock offset between the client and the
+// server until the context is canceled.
+func (a *Application) ping(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		b, err := json.Marshal(event.PingEvent{
+			Event:  event.Ping,
+			Origin: time.Now().UnixNano(),
+		})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		err = a.send(conn, b)
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// timestamp sets the presentation time of the frames, converted to the
+// server's clock. Frames are left untimestamped if scheduled playout is
+// disabled or the clocks have not been synchronized yet.
+func (a *Application) timestamp(events []event.Event) {
+	if a.PlayoutDelay == 0 || !a.clock.Synced() {
+		return
+	}
+
+	ts := a.clock.Remote(time.Now().Add(a.PlayoutDelay)).UnixNano()
+
+	for i, e := range events {
+		if e, ok := e.(event.SetLedsEvent); ok {
+			e.Timestamp = ts
+			events[i] = e
+		}
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"image/color"
+	"time"
 
 	"github.com/lucasb-eyer/go-colorful"
 	"golang.org/x/exp/slices"
@@ -13,6 +14,9 @@ import (
 	"ledctl3/internal/client/controller/video"
 	"ledctl3/internal/client/controller/video/capturer/bitblt"
 	"ledctl3/internal/client/controller/video/capturer/dxgi"
+	clr "ledctl3/pkg/color"
+	"ledctl3/pkg/filter"
+	"ledctl3/pkg/layout"
 )
 
 type CapturerType string
@@ -80,7 +84,7 @@ func (a *Application) validateConfig(c config.Config) error {
 		return err
 	}
 
-	err = a.validateDisplayConfigs(c.Displays)
+	err = a.validateDisplayConfigs(c.Displays, c.Segments)
 	if err != nil {
 		return err
 	}
@@ -90,6 +94,11 @@ func (a *Application) validateConfig(c config.Config) error {
 		return err
 	}
 
+	err = validateFilter(c.Video.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid video filter: %w", err)
+	}
+
 	return nil
 }
 
@@ -98,6 +107,16 @@ func (a *Application) validateSegments(segs []config.Segment) error {
 		if seg.Leds < 1 || seg.Leds > 1024 {
 			return fmt.Errorf("invalid LED count for segment %d", seg.Id)
 		}
+
+		err := validateFilter(seg.AudioFilter)
+		if err != nil {
+			return fmt.Errorf("invalid audio filter for segment %d: %w", seg.Id, err)
+		}
+
+		err = validateFilter(seg.VideoFilter)
+		if err != nil {
+			return fmt.Errorf("invalid video filter for segment %d: %w", seg.Id, err)
+		}
 	}
 
 	return nil
@@ -130,10 +149,14 @@ func (a *Application) validateServer(srv config.Server) error {
 		return fmt.Errorf("invalid server brightness")
 	}
 
+	if srv.PlayoutDelay < 0 || srv.PlayoutDelay > 1000 {
+		return fmt.Errorf("invalid server playout delay")
+	}
+
 	return nil
 }
 
-func (a *Application) validateDisplayConfigs(displayConfigs [][]config.Display) error {
+func (a *Application) validateDisplayConfigs(displayConfigs [][]config.Display, segs []config.Segment) error {
 	for _, cfg := range displayConfigs {
 		for i, d := range cfg {
 			if d.Width < 1 || d.Width > 7680 {
@@ -148,6 +171,52 @@ func (a *Application) validateDisplayConfigs(displayConfigs [][]config.Display)
 				return fmt.Errorf("invalid framerate for display %d", i)
 			}
 
+			err := videoProcessing(d.Processing).Validate()
+			if err != nil {
+				return fmt.Errorf("invalid processing for display %d: %w", i, err)
+			}
+
+			err = videoSmoothing(d.Smoothing).Validate()
+			if err != nil {
+				return fmt.Errorf("invalid smoothing for display %d: %w", i, err)
+			}
+
+			regions, err := layoutRegions(d)
+			if err != nil {
+				return fmt.Errorf("invalid layout for display %d: %w", i, err)
+			}
+
+			for id, r := range regions {
+				leds := -1
+				for _, seg := range segs {
+					if seg.Id == id {
+						leds = seg.Leds
+					}
+				}
+
+				if leds == -1 {
+					return fmt.Errorf("invalid segment %d in layout of display %d", id, i)
+				}
+
+				if len(r) != leds {
+					return fmt.Errorf("layout of display %d places %d LEDs of segment %d, which has %d", i, len(r), id, leds)
+				}
+			}
+
+			for _, seg := range d.Segments {
+				if seg.Depth < 0 || seg.DepthPercent < 0 || seg.DepthPercent > 100 {
+					return fmt.Errorf("invalid sampling depth for segment %d of display %d", seg.Id, i)
+				}
+
+				if seg.EdgeWeight < 0 || seg.EdgeWeight > 1 {
+					return fmt.Errorf("invalid edge weight for segment %d of display %d", seg.Id, i)
+				}
+
+				if seg.Overlap < 0 || seg.Overlap > 1 {
+					return fmt.Errorf("invalid overlap for segment %d of display %d", seg.Id, i)
+				}
+			}
+
 			//v1 := validateBounds(d.Width, d.Height, d.Bounds.From.X, d.Bounds.From.Y)
 			//if !v1 {
 			//	return fmt.Errorf("invalid bounds for display %d (from)", i)
@@ -193,8 +262,8 @@ func (a *Application) validateAudioConfig(cfg config.Audio) error {
 			return errors.New("a color profile requires a minimum of two colors")
 		}
 
-		for _, hex := range prof.Colors {
-			_, err := colorful.Hex(hex)
+		for _, c := range prof.Colors {
+			_, err := parseColor(c)
 			if err != nil {
 				return err
 			}
@@ -213,9 +282,23 @@ func (a *Application) validateAudioConfig(cfg config.Audio) error {
 		return errors.New("black point has to be a floating point number in the range [0-1)")
 	}
 
+	err := validateFilter(cfg.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid audio filter: %w", err)
+	}
+
 	return nil
 }
 
+func validateFilter(f *config.Filter) error {
+	if f == nil {
+		return nil
+	}
+
+	_, err := filter.New(filterSpec(f, filter.Spec{}))
+	return err
+}
+
 func (a *Application) applyConfig(c config.Config) (err error) {
 	switch CapturerType(c.CaptureType) {
 	case DXGI:
@@ -234,13 +317,20 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 	a.StripType = stripTypes[c.Server.StripType]
 	a.GpioPin = c.Server.GpioPin
 	a.Brightness = c.Server.Brightness
+	a.PlayoutDelay = time.Duration(c.Server.PlayoutDelay) * time.Millisecond
+
+	// colors are averaged over windowSize frames unless a filter is set
+	audioFilter := filterSpec(c.Audio.Filter, filter.Spec{Type: filter.EWMA, Window: c.Audio.WindowSize})
+	videoFilter := filterSpec(c.Video.Filter, filter.Spec{Type: filter.None})
 
 	a.Segments = []Segment{}
 	for _, s := range c.Segments {
 		a.Segments = append(
 			a.Segments, Segment{
-				Id:   s.Id,
-				Leds: s.Leds,
+				Id:          s.Id,
+				Leds:        s.Leds,
+				AudioFilter: filterSpec(s.AudioFilter, audioFilter),
+				VideoFilter: filterSpec(s.VideoFilter, videoFilter),
 			},
 		)
 	}
@@ -265,13 +355,20 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 			//	return fmt.Errorf("segment not found for display %d of config %d", j, i)
 			//}
 
+			regions, err := layoutRegions(d)
+			if err != nil {
+				return err
+			}
+
 			var segs []video.Segment
 
 			for _, dseg := range d.Segments {
 				leds := 0
+				spec := videoFilter
 				for _, aseg := range a.Segments {
 					if aseg.Id == dseg.Id {
 						leds = aseg.Leds
+						spec = aseg.VideoFilter
 					}
 				}
 
@@ -285,6 +382,39 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 					From:    video.Vector2(dseg.From),
 					To:      video.Vector2(dseg.To),
 					Reverse: dseg.Reverse,
+					Filter:  spec,
+					Sampling: video.Sampling{
+						Depth:        dseg.Depth,
+						DepthPercent: dseg.DepthPercent,
+						EdgeWeight:   dseg.EdgeWeight,
+						Overlap:      dseg.Overlap,
+					},
+					Regions: regions[dseg.Id],
+				})
+
+				delete(regions, dseg.Id)
+			}
+
+			// segments only placed by the layout
+			ids := make([]int, 0, len(regions))
+			for id := range regions {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+
+			for _, id := range ids {
+				spec := videoFilter
+				for _, aseg := range a.Segments {
+					if aseg.Id == id {
+						spec = aseg.VideoFilter
+					}
+				}
+
+				segs = append(segs, video.Segment{
+					Id:      id,
+					Leds:    len(regions[id]),
+					Filter:  spec,
+					Regions: regions[id],
 				})
 			}
 
@@ -292,11 +422,14 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 				parsedCfg, video.DisplayConfig{
 					Id: j,
 					//SegmentId:      d.Segment,
-					Width:    d.Width,
-					Height:   d.Height,
-					Left:     d.Left,
-					Top:      d.Top,
-					Segments: segs,
+					Width:      d.Width,
+					Height:     d.Height,
+					Left:       d.Left,
+					Top:        d.Top,
+					Segments:   segs,
+					DetectBars: d.DetectBars,
+					Processing: videoProcessing(d.Processing),
+					Smoothing:  videoSmoothing(d.Smoothing),
 					//HorizontalLeds: d.HorizontalLeds,
 					//VerticalLeds:   d.VerticalLeds,
 					Framerate: d.Framerate,
@@ -314,8 +447,8 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 
 	for _, prof := range c.Audio.Colors.Profiles {
 		if prof.Name == c.Audio.Colors.Selected {
-			for _, hex := range prof.Colors {
-				clr, err := colorful.Hex(hex)
+			for _, c := range prof.Colors {
+				clr, err := parseColor(c)
 				if err != nil {
 					return err
 				}
@@ -332,3 +465,92 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 
 	return nil
 }
+
+// layoutRegions loads the layout of a display and returns the regions of the
+// LEDs of each segment it places.
+func layoutRegions(d config.Display) (map[int][]layout.Rect, error) {
+	regions := map[int][]layout.Rect{}
+
+	if d.Layout == "" {
+		return regions, nil
+	}
+
+	l, err := layout.Load(d.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seg := range l.Segments {
+		r, _, err := l.Regions(seg.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		regions[seg.Id] = r
+	}
+
+	return regions, nil
+}
+
+// filterSpec converts a configured filter to a spec, or returns the fallback
+// if no filter is configured.
+func filterSpec(f *config.Filter, fallback filter.Spec) filter.Spec {
+	if f == nil {
+		return fallback
+	}
+
+	return filter.Spec{
+		Type:             filter.Type(f.Type),
+		Window:           f.Window,
+		Attack:           time.Duration(f.Attack) * time.Millisecond,
+		Release:          time.Duration(f.Release) * time.Millisecond,
+		MinCutoff:        f.MinCutoff,
+		Beta:             f.Beta,
+		DerivativeCutoff: f.DerivativeCutoff,
+		Cut:              f.Cut,
+	}
+}
+
+// videoProcessing converts configured color processing to the settings of the
+// video visualizer.
+func videoProcessing(p *config.Processing) video.Processing {
+	if p == nil {
+		return video.Processing{}
+	}
+
+	return video.Processing{
+		Saturation:    p.Saturation,
+		Red:           p.Red,
+		Green:         p.Green,
+		Blue:          p.Blue,
+		Threshold:     p.Threshold,
+		MaxBrightness: p.MaxBrightness,
+		Temperature:   p.Temperature,
+	}
+}
+
+// videoSmoothing converts configured smoothing to the settings of the video
+// visualizer.
+func videoSmoothing(s *config.Smoothing) video.Smoothing {
+	if s == nil {
+		return video.Smoothing{}
+	}
+
+	return video.Smoothing{
+		Strength: s.Strength,
+		Cut:      s.Cut,
+	}
+}
+
+// parseColor parses a profile color in any form the server accepts. Profiles
+// have no white channel, so the color is opaque.
+func parseColor(s string) (colorful.Color, error) {
+	c, err := clr.FromString(s)
+	if err != nil {
+		return colorful.Color{}, err
+	}
+
+	r, g, b, _ := c.RGBA()
+
+	return colorful.Color{R: float64(r) / 0xffff, G: float64(g) / 0xffff, B: float64(b) / 0xffff}, nil
+}
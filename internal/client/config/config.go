@@ -16,6 +16,7 @@ type Config struct {
 	Server      Server      `yaml:"server" json:"server"`
 	Displays    [][]Display `yaml:"displays" json:"displays"`
 	Audio       Audio       `yaml:"audio" json:"audio"`
+	Video       Video       `yaml:"video" json:"video"`
 	Segments    []Segment   `yaml:"segments" json:"segments"`
 }
 
@@ -23,6 +24,32 @@ type Audio struct {
 	Colors     Colors  `yaml:"colors" json:"colors"`
 	WindowSize int     `yaml:"windowSize" json:"windowSize"`
 	BlackPoint float64 `yaml:"blackPoint" json:"blackPoint"`
+	// Filter smooths the audio visualization. Without it, colors are
+	// averaged over windowSize frames.
+	Filter *Filter `yaml:"filter,omitempty" json:"filter,omitempty"`
+}
+
+type Video struct {
+	Filter *Filter `yaml:"filter,omitempty" json:"filter,omitempty"`
+}
+
+// Filter selects how colors are smoothed between frames. Type is one of none,
+// ewma, attackRelease, oneEuro and median; the other fields apply to some of
+// them and fall back to defaults when left out.
+type Filter struct {
+	Type string `yaml:"type" json:"type"`
+	// Window is the amount of frames of the ewma and median filters.
+	Window int `yaml:"window,omitempty" json:"window,omitempty"`
+	// Attack and Release are in milliseconds.
+	Attack  int `yaml:"attack,omitempty" json:"attack,omitempty"`
+	Release int `yaml:"release,omitempty" json:"release,omitempty"`
+	// MinCutoff and DerivativeCutoff are in Hz.
+	MinCutoff        float64 `yaml:"minCutoff,omitempty" json:"minCutoff,omitempty"`
+	Beta             float64 `yaml:"beta,omitempty" json:"beta,omitempty"`
+	DerivativeCutoff float64 `yaml:"derivativeCutoff,omitempty" json:"derivativeCutoff,omitempty"`
+	// Cut from 0 to 1 resets the filter on frames that change more than it
+	// on average.
+	Cut float64 `yaml:"cut,omitempty" json:"cut,omitempty"`
 }
 
 type Colors struct {
@@ -36,15 +63,19 @@ type Profile struct {
 }
 
 type Server struct {
-	Host       string `yaml:"host" json:"host"`
-	Port       int    `yaml:"port" json:"port"`
-	Leds       int    `yaml:"leds" json:"leds"`
-	StripType  string `yaml:"stripType" json:"stripType"`
-	GpioPin    int    `yaml:"gpioPin" json:"gpioPin"`
-	Brightness int    `yaml:"brightness" json:"brightness"`
-	BlackPoint int    `yaml:"blackPoint" json:"blackPoint"`
+	Host         string `yaml:"host" json:"host"`
+	Port         int    `yaml:"port" json:"port"`
+	Leds         int    `yaml:"leds" json:"leds"`
+	StripType    string `yaml:"stripType" json:"stripType"`
+	GpioPin      int    `yaml:"gpioPin" json:"gpioPin"`
+	Brightness   int    `yaml:"brightness" json:"brightness"`
+	BlackPoint   int    `yaml:"blackPoint" json:"blackPoint"`
+	PlayoutDelay int    `yaml:"playoutDelay" json:"playoutDelay"`
 }
 
+// Display describes a monitor as it is physically mounted. Width, height and
+// the segments are those of the unrotated monitor, so that they stay the same
+// when the desktop is rotated on it.
 type Display struct {
 	Segments  []DisplaySegment `yaml:"segments" json:"segments"`
 	Width     int              `yaml:"width" json:"width"`
@@ -52,6 +83,44 @@ type Display struct {
 	Left      int              `yaml:"left" json:"left"`
 	Top       int              `yaml:"top" json:"top"`
 	Framerate int              `yaml:"framerate" json:"framerate"`
+	// DetectBars samples the picture inside the black bars of letterboxed
+	// and pillarboxed video.
+	DetectBars bool `yaml:"detectBars" json:"detectBars"`
+	// Processing adjusts the captured colors before they are sent.
+	Processing *Processing `yaml:"processing,omitempty" json:"processing,omitempty"`
+	// Smoothing smooths the colors over time, except on scene cuts.
+	Smoothing *Smoothing `yaml:"smoothing,omitempty" json:"smoothing,omitempty"`
+	// Layout is the name of a layout file that places the LEDs of segments
+	// on the screen one by one. Segments in the layout sample their LEDs'
+	// regions instead of spreading them between from and to, and don't need
+	// to be listed in segments.
+	Layout string `yaml:"layout,omitempty" json:"layout,omitempty"`
+}
+
+type Smoothing struct {
+	// Strength from 0 to 1 is how much colors are smoothed. It applies to
+	// segments without a video filter of their own.
+	Strength float64 `yaml:"strength" json:"strength"`
+	// Cut from 0 to 1 is the average change between frames that counts as a
+	// scene cut, which isn't smoothed.
+	Cut float64 `yaml:"cut,omitempty" json:"cut,omitempty"`
+}
+
+// Processing adjusts the colors captured from a display in linear light.
+// Fields left out leave the colors as they are.
+type Processing struct {
+	// Saturation scales the saturation, from 0 to 4.
+	Saturation float64 `yaml:"saturation,omitempty" json:"saturation,omitempty"`
+	// Red, Green and Blue are channel gains from 0 to 4.
+	Red   float64 `yaml:"red,omitempty" json:"red,omitempty"`
+	Green float64 `yaml:"green,omitempty" json:"green,omitempty"`
+	Blue  float64 `yaml:"blue,omitempty" json:"blue,omitempty"`
+	// Threshold from 0 to 1 turns colors darker than it black.
+	Threshold float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	// MaxBrightness from 0 to 1 limits the luminance of each LED.
+	MaxBrightness float64 `yaml:"maxBrightness,omitempty" json:"maxBrightness,omitempty"`
+	// Temperature is the white balance in Kelvin.
+	Temperature float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
 }
 
 type DisplaySegment struct {
@@ -59,6 +128,15 @@ type DisplaySegment struct {
 	From    Vector2 `yaml:"from" json:"from"`
 	To      Vector2 `yaml:"to" json:"to"`
 	Reverse bool    `yaml:"reverse" json:"reverse"`
+	// Depth is how far into the screen the LEDs sample, in pixels, or in
+	// percent of the screen with DepthPercent. Without either the whole
+	// rectangle is sampled.
+	Depth        int     `yaml:"depth,omitempty" json:"depth,omitempty"`
+	DepthPercent float64 `yaml:"depthPercent,omitempty" json:"depthPercent,omitempty"`
+	// EdgeWeight from 0 to 1 weighs pixels close to the screen edge more.
+	EdgeWeight float64 `yaml:"edgeWeight,omitempty" json:"edgeWeight,omitempty"`
+	// Overlap from 0 to 1 widens the area of each LED into its neighbours.
+	Overlap float64 `yaml:"overlap,omitempty" json:"overlap,omitempty"`
 }
 
 type Bounds struct {
@@ -74,6 +152,10 @@ type Vector2 struct {
 type Segment struct {
 	Id   int `yaml:"id" json:"id"`
 	Leds int `yaml:"leds" json:"leds"`
+	// AudioFilter and VideoFilter override the filter of the visualizers for
+	// this segment.
+	AudioFilter *Filter `yaml:"audioFilter,omitempty" json:"audioFilter,omitempty"`
+	VideoFilter *Filter `yaml:"videoFilter,omitempty" json:"videoFilter,omitempty"`
 }
 
 func (c Config) Save() error {
@@ -201,6 +283,11 @@ func createDefault() (Config, error) {
 			},
 			WindowSize: 40,
 			BlackPoint: 0.2,
+			Filter: &Filter{
+				Type:    "attackRelease",
+				Attack:  10,
+				Release: 300,
+			},
 		},
 	}
 
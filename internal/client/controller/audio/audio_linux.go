@@ -2,6 +2,7 @@ package audio
 
 import (
 	"ledctl3/internal/client/visualizer"
+	"ledctl3/pkg/filter"
 )
 
 type Visualizer struct {
@@ -11,8 +12,9 @@ type Visualizer struct {
 }
 
 type Segment struct {
-	Id   int
-	Leds int
+	Id     int
+	Leds   int
+	Filter filter.Spec
 }
 
 func (v *Visualizer) Start() error {
@@ -12,7 +12,7 @@ import (
 	"unsafe"
 
 	wca_ami "ledctl3/internal/client/controller/audio/wca-ami"
-	"ledctl3/pkg/pixavg"
+	"ledctl3/pkg/filter"
 
 	"github.com/VividCortex/ewma"
 	"github.com/go-ole/go-ole"
@@ -44,14 +44,13 @@ type Visualizer struct {
 	processing bool
 	frames     chan frame
 
-	gradient   gradient.Gradient
+	gradient   *gradient.Gradient
 	windowSize int
 
 	stats Statistics
 
-	// average holds a pixavg.Average for each segment. The decay rate
-	// is affected by windowSize.
-	average map[int]pixavg.Average
+	// filters holds the filter that smooths the colors of each segment.
+	filters map[int]*filter.Colors
 
 	// freqMax is a moving average of the maximum magnitude observed between
 	// different audio frames. It helps make smoother transitions between
@@ -65,8 +64,9 @@ type Visualizer struct {
 }
 
 type Segment struct {
-	Id   int
-	Leds int
+	Id     int
+	Leds   int
+	Filter filter.Spec
 }
 
 // frame represents an audio frame
@@ -407,8 +407,7 @@ func (v *Visualizer) processFrame(samples []float64, peak float64) error {
 				colors[i] = color.RGBA{}
 			}
 
-			v.average[seg.Id].Add(colors)
-			colors = v.average[seg.Id].Current()
+			v.filters[seg.Id].Apply(colors, now)
 
 			if seg.Id == 0 {
 				out := ""
@@ -421,7 +420,7 @@ func (v *Visualizer) processFrame(samples []float64, peak float64) error {
 
 			segs = append(segs, visualizer.Segment{
 				Id:  seg.Id,
-				Pix: colors,
+				Pix: visualizer.AppendColors(nil, colors...),
 			})
 		}
 
@@ -453,8 +452,8 @@ func (v *Visualizer) processFrame(samples []float64, peak float64) error {
 		for i := 0; i < seg.Leds; i++ {
 			magn := freqs.At(float64(i) / float64(seg.Leds-1))
 
-			c := v.gradient.GetInterpolatedColor(magn)
-			clr, _ := colorful.MakeColor(c)
+			c := v.gradient.At(magn)
+			clr := colorful.Color{R: float64(c.R) / 0xffff, G: float64(c.G) / 0xffff, B: float64(c.B) / 0xffff}
 
 			// Extract HSV color info, we'll use the Value to adjust the
 			// brightness of the colors depending on frequency magnitude.
@@ -481,9 +480,8 @@ func (v *Visualizer) processFrame(samples []float64, peak float64) error {
 			colors = append(colors, hsv)
 		}
 
-		// Add the color data to the moving average accumulator for this segment
-		v.average[seg.Id].Add(colors)
-		colors = v.average[seg.Id].Current()
+		// Smooth the colors of this segment between frames
+		v.filters[seg.Id].Apply(colors, now)
 
 		// Create the pix slice from the color data
 		//pix := make([]uint8, len(colors))
@@ -512,7 +510,7 @@ func (v *Visualizer) processFrame(samples []float64, peak float64) error {
 
 		segs = append(segs, visualizer.Segment{
 			Id:  seg.Id,
-			Pix: colors,
+			Pix: visualizer.AppendColors(nil, colors...),
 		})
 	}
 
@@ -597,25 +595,24 @@ func New(opts ...Option) (v *Visualizer, err error) {
 		}
 	}
 
-	v.gradient, err = gradient.New(v.colors...)
+	v.gradient, err = gradient.New(gradient.Uniform(v.colors...))
 	if err != nil {
 		return nil, err
 	}
 
 	v.events = make(chan visualizer.UpdateEvent, len(v.segments))
 
-	//v.average = make(map[int]sliceewma.MovingAverage, len(v.segments))
-
-	v.average = make(map[int]pixavg.Average, len(v.segments))
+	v.filters = make(map[int]*filter.Colors, len(v.segments))
 
 	v.freqMax = ewma.NewMovingAverage(float64(v.windowSize) * 8)
 
 	for _, seg := range v.segments {
-		prev := make([]color.Color, seg.Leds)
-		for i := 0; i < len(prev); i++ {
-			prev[i] = color.RGBA{}
+		f, err := filter.New(seg.Filter)
+		if err != nil {
+			return nil, err
 		}
-		v.average[seg.Id] = pixavg.New(v.windowSize, prev, 2)
+
+		v.filters[seg.Id] = filter.NewColors(f)
 	}
 
 	return v, nil
@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"ledctl3/internal/client/controller/video"
 	"ledctl3/internal/client/visualizer"
 	"ledctl3/internal/pkg/event"
 
@@ -31,15 +33,34 @@ type timing struct {
 
 type Statistics struct {
 	AverageProcessingTime time.Duration
+	// Video holds the statistics of the display visualizer, like the crop
+	// of black bars.
+	Video video.Statistics
 }
 
 func (ctl *Controller) Statistics() Statistics {
 	ctl.timingMux.Lock()
 	defer ctl.timingMux.Unlock()
 
-	return Statistics{
+	stats := Statistics{
 		AverageProcessingTime: time.Duration(ctl.timing.process.Value()),
 	}
+
+	if v, ok := ctl.displayVisualizer.(*video.Visualizer); ok {
+		stats.Video = v.Statistics()
+	}
+
+	return stats
+}
+
+// SetVideoProcessing changes the color processing of a display at runtime.
+func (ctl *Controller) SetVideoProcessing(display int, p video.Processing) error {
+	v, ok := ctl.displayVisualizer.(*video.Visualizer)
+	if !ok {
+		return errors.New("no display visualizer")
+	}
+
+	return v.SetProcessing(display, p)
 }
 
 func New(opts ...Option) (*Controller, error) {
@@ -135,19 +156,24 @@ func (ctl *Controller) SetMode(mode Mode) error {
 				events := []event.Event{}
 
 				for _, seg := range evt.Segments {
-					pix := make([]uint8, 0, len(seg.Pix)*4)
-					for _, c := range seg.Pix {
-						r, g, b, a := c.RGBA()
-						pix = append(pix, uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8))
-					}
+					// Send plain 16-bit RGB, the server derives the white
+					// channel for RGBW strips and dithers the output. The
+					// pixels are copied as the visualizer reuses its buffers.
+					pix := make([]uint8, len(seg.Pix))
+					copy(pix, seg.Pix)
 
 					events = append(events, event.SetLedsEvent{
 						Event:     event.SetLeds,
 						SegmentId: seg.Id,
 						Pix:       pix,
+						Bits:      16,
 					})
 				}
 
+				if evt.Release != nil {
+					evt.Release()
+				}
+
 				ctl.events <- events
 			}
 		}()
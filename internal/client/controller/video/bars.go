@@ -0,0 +1,205 @@
+package video
+
+import (
+	"image"
+	"time"
+)
+
+const (
+	// barLevel is the brightest a channel of a black bar can be. Video
+	// encoders rarely output pure black.
+	barLevel = 0x18
+	// darkLevel is the average brightness below which a frame is considered
+	// too dark to tell black bars from the picture.
+	darkLevel = 0x0c
+	// barInterval is how often frames are analysed.
+	barInterval = 100 * time.Millisecond
+	// growHold is how long larger bars must be seen before the crop shrinks
+	// the picture, and shrinkHold how long smaller ones must be seen before
+	// it grows again. Picture content is lost while the crop is too small,
+	// so it grows back sooner.
+	growHold   = 2 * time.Second
+	shrinkHold = 500 * time.Millisecond
+	// barStep is the distance between the pixels sampled along a line.
+	barStep = 8
+)
+
+// barDetector finds the black bars of letterboxed and pillarboxed video. A
+// new crop must be seen consistently for a while before it is applied, and
+// dark frames are ignored, so the crop stays put through fades and dark
+// scenes.
+type barDetector struct {
+	bounds image.Rectangle
+	crop   image.Rectangle
+
+	candidate image.Rectangle
+	since     time.Time
+	last      time.Time
+}
+
+func newBarDetector(bounds image.Rectangle) *barDetector {
+	return &barDetector{
+		bounds:    bounds,
+		crop:      bounds,
+		candidate: bounds,
+	}
+}
+
+// update analyses a frame and returns whether the crop changed.
+func (d *barDetector) update(src *image.RGBA, now time.Time) bool {
+	if now.Sub(d.last) < barInterval {
+		return false
+	}
+
+	d.last = now
+
+	c, ok := d.measure(src)
+	if !ok {
+		return false
+	}
+
+	if !d.near(c, d.candidate) {
+		d.candidate = c
+		d.since = now
+		return false
+	}
+
+	if d.near(c, d.crop) {
+		return false
+	}
+
+	hold := growHold
+	if d.crop.In(c) {
+		hold = shrinkHold
+	}
+
+	if now.Sub(d.since) < hold {
+		return false
+	}
+
+	d.crop = d.candidate
+
+	return true
+}
+
+// measure returns the active picture area of a frame. Bars must be symmetric,
+// and can take up at most a third of the frame on each side.
+func (d *barDetector) measure(src *image.RGBA) (image.Rectangle, bool) {
+	b := d.bounds
+
+	if d.brightness(src) < darkLevel {
+		return image.Rectangle{}, false
+	}
+
+	row := func(y int) bool {
+		return d.bright(src, image.Pt(b.Min.X, y), image.Pt(barStep, 0), b.Dx()/barStep)
+	}
+
+	col := func(x int) bool {
+		return d.bright(src, image.Pt(x, b.Min.Y), image.Pt(0, barStep), b.Dy()/barStep)
+	}
+
+	top := scan(b.Min.Y, 1, b.Dy()/3, row)
+	bottom := scan(b.Max.Y-1, -1, b.Dy()/3, row)
+	left := scan(b.Min.X, 1, b.Dx()/3, col)
+	right := scan(b.Max.X-1, -1, b.Dx()/3, col)
+
+	// bars of different sizes are content like subtitles or a dark part of
+	// the picture, which tells nothing about the bars
+	tx, ty := d.tolerance()
+	if abs(top-bottom) >= ty || abs(left-right) >= tx {
+		return image.Rectangle{}, false
+	}
+
+	v, h := min(top, bottom), min(left, right)
+
+	return image.Rect(b.Min.X+h, b.Min.Y+v, b.Max.X-h, b.Max.Y-v), true
+}
+
+// scan returns the amount of lines from start in the direction of step until
+// a bright one, up to limit.
+func scan(start, step, limit int, bright func(i int) bool) int {
+	for n := 0; n < limit; n++ {
+		if bright(start + n*step) {
+			return n
+		}
+	}
+
+	return limit
+}
+
+// bright reports whether at least one in 32 of the n pixels from p in steps of
+// step is brighter than a black bar.
+func (d *barDetector) bright(src *image.RGBA, p, step image.Point, n int) bool {
+	need := n/32 + 1
+
+	for i := 0; i < n; i++ {
+		off := src.PixOffset(p.X, p.Y)
+		if src.Pix[off] > barLevel || src.Pix[off+1] > barLevel || src.Pix[off+2] > barLevel {
+			need--
+			if need == 0 {
+				return true
+			}
+		}
+
+		p = p.Add(step)
+	}
+
+	return false
+}
+
+// brightness returns the average of the brightest channel of a grid of pixels.
+func (d *barDetector) brightness(src *image.RGBA) int {
+	var sum, n int
+
+	step := barStep * 4
+	for y := d.bounds.Min.Y; y < d.bounds.Max.Y; y += step {
+		for x := d.bounds.Min.X; x < d.bounds.Max.X; x += step {
+			off := src.PixOffset(x, y)
+			sum += max(max(int(src.Pix[off]), int(src.Pix[off+1])), int(src.Pix[off+2]))
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0
+	}
+
+	return sum / n
+}
+
+// near reports whether two crops differ by less than one percent of the
+// frame on every side, which absorbs noise and compression artifacts.
+func (d *barDetector) near(a, b image.Rectangle) bool {
+	tx, ty := d.tolerance()
+
+	return abs(a.Min.X-b.Min.X) < tx && abs(a.Max.X-b.Max.X) < tx &&
+		abs(a.Min.Y-b.Min.Y) < ty && abs(a.Max.Y-b.Max.Y) < ty
+}
+
+// tolerance returns the difference in size of bars that counts as noise.
+func (d *barDetector) tolerance() (int, int) {
+	return d.bounds.Dx()/100 + 1, d.bounds.Dy()/100 + 1
+}
+
+// cropRect maps a rectangle on a frame with the given bounds into the crop.
+func cropRect(r, bounds, crop image.Rectangle) image.Rectangle {
+	scale := func(v, min, size, cmin, csize int) int {
+		return cmin + (v-min)*csize/size
+	}
+
+	return image.Rect(
+		scale(r.Min.X, bounds.Min.X, bounds.Dx(), crop.Min.X, crop.Dx()),
+		scale(r.Min.Y, bounds.Min.Y, bounds.Dy(), crop.Min.Y, crop.Dy()),
+		scale(r.Max.X, bounds.Min.X, bounds.Dx(), crop.Min.X, crop.Dx()),
+		scale(r.Max.Y, bounds.Min.Y, bounds.Dy(), crop.Min.Y, crop.Dy()),
+	)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
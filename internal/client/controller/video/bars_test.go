@@ -0,0 +1,123 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// letterbox creates a frame with bars of the given size around a picture of
+// the given color.
+func letterbox(width, height, bar, pillar int, c color.RGBA) *image.RGBA {
+	active := image.Rect(pillar, bar, width-pillar, height-bar)
+
+	return newImage(width, height, func(x, y int) color.RGBA {
+		if image.Pt(x, y).In(active) {
+			return c
+		}
+
+		return color.RGBA{A: 0xff}
+	})
+}
+
+func TestBarDetector(t *testing.T) {
+	gray := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	bounds := image.Rect(0, 0, 320, 180)
+
+	d := newBarDetector(bounds)
+	now := time.Now()
+
+	feed := func(img *image.RGBA, dur time.Duration) bool {
+		changed := false
+		for end := now.Add(dur); now.Before(end); now = now.Add(barInterval) {
+			changed = d.update(img, now) || changed
+		}
+
+		return changed
+	}
+
+	// 21:9 video on a 16:9 screen is cropped after a while
+	wide := letterbox(320, 180, 22, 0, gray)
+	if feed(wide, growHold/2) {
+		t.Fatal("expected the crop to hold")
+	}
+
+	if !feed(wide, growHold) {
+		t.Fatal("expected the crop to change")
+	}
+
+	if d.crop != image.Rect(0, 22, 320, 158) {
+		t.Fatalf("unexpected crop %v", d.crop)
+	}
+
+	// dark frames don't move it
+	dark := letterbox(320, 180, 60, 0, color.RGBA{R: 0x10, A: 0xff})
+	if feed(dark, 2*growHold) || d.crop != image.Rect(0, 22, 320, 158) {
+		t.Fatalf("expected the crop to hold through dark frames, got %v", d.crop)
+	}
+
+	// subtitles in a bar don't either
+	subtitled := letterbox(320, 180, 22, 0, gray)
+	for x := 100; x < 220; x++ {
+		subtitled.SetRGBA(x, 165, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	}
+
+	if feed(subtitled, 2*growHold) {
+		t.Fatalf("expected the crop to hold with subtitles, got %v", d.crop)
+	}
+
+	// bars going away are picked up sooner
+	full := letterbox(320, 180, 0, 0, gray)
+	if !feed(full, shrinkHold+2*barInterval) || d.crop != bounds {
+		t.Fatalf("expected the crop to be removed, got %v", d.crop)
+	}
+
+	// 4:3 video is pillarboxed
+	narrow := letterbox(320, 180, 0, 40, gray)
+	if !feed(narrow, growHold+2*barInterval) {
+		t.Fatal("expected the crop to change")
+	}
+
+	if d.crop != image.Rect(40, 0, 280, 180) {
+		t.Fatalf("unexpected crop %v", d.crop)
+	}
+}
+
+func TestCropSegments(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	img := letterbox(320, 180, 22, 0, red)
+
+	p, err := newPipeline(&fakeDisplay{width: 320, height: 180}, DisplayConfig{
+		Width:      320,
+		Height:     180,
+		DetectBars: true,
+		Segments: []Segment{
+			{Id: 0, Leds: 4, From: Vector2{0, 0}, To: Vector2{320, 10}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := p.segments[0].values
+
+	// the top segment samples the bar until the crop is detected
+	now := time.Now()
+	p.src = *img
+	p.segments[0].sample(&p.src, p.proc, make([]uint8, 4*6), now)
+	assertValues(t, []float64{0, 0, 0}, values)
+
+	for end := now.Add(2 * growHold); now.Before(end); now = now.Add(barInterval) {
+		if p.bars.update(&p.src, now) {
+			p.setCrop(p.bars.crop)
+		}
+	}
+
+	if p.Crop() != image.Rect(0, 22, 320, 158) {
+		t.Fatalf("unexpected crop %v", p.Crop())
+	}
+
+	p.segments[0].sample(&p.src, p.proc, make([]uint8, 4*6), now)
+	assertValues(t, []float64{1, 0, 0, 1, 0, 0}, values)
+}
@@ -34,6 +34,17 @@ func (c *dxgiCapturer) All() ([]video.Display, error) {
 
 		d.buf = image.NewNRGBA(bounds)
 
+		if d.orientation != video.Landscape {
+			// frames are duplicated unrotated, with the size of the
+			// physical display
+			w, h := d.width, d.height
+			if d.orientation != video.LandscapeFlipped {
+				w, h = h, w
+			}
+
+			d.raw = image.NewNRGBA(image.Rect(0, 0, w, h))
+		}
+
 		ds = append(ds, d)
 
 		i++
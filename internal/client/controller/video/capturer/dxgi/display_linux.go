@@ -9,13 +9,15 @@ import (
 )
 
 type display struct {
-	index  int
-	id     int
-	width  int
-	height int
-	x      int
-	y      int
-	buf    *image.RGBA
+	index       int
+	id          int
+	width       int
+	height      int
+	x           int
+	y           int
+	buf         *image.RGBA
+	raw         *image.NRGBA
+	orientation video.Orientation
 }
 
 func (d *display) Id() int {
@@ -59,5 +61,5 @@ func (d *display) reset() error {
 }
 
 func (d *display) Orientation() video.Orientation {
-	return video.Landscape
+	return d.orientation
 }
@@ -15,13 +15,16 @@ import (
 var ErrNoFrame = fmt.Errorf("no frame")
 
 type display struct {
-	index       int
-	id          int
-	width       int
-	height      int
-	x           int
-	y           int
-	buf         *image.NRGBA
+	index  int
+	id     int
+	width  int
+	height int
+	x      int
+	y      int
+	buf    *image.NRGBA
+	// raw holds the frames of rotated displays, which are duplicated in the
+	// orientation of the physical display, before they are rotated into buf.
+	raw         *image.NRGBA
 	dev         *d3d.ID3D11Device
 	devCtx      *d3d.ID3D11DeviceContext
 	ddup        *d3d.OutputDuplicator
@@ -105,7 +108,12 @@ func (d *display) Orientation() video.Orientation {
 }
 
 func (d *display) nextFrame() ([]byte, error) {
-	err := d.ddup.GetImage(d.buf, 0)
+	img := d.buf
+	if d.orientation != video.Landscape {
+		img = d.raw
+	}
+
+	err := d.ddup.GetImage(img, 0)
 	if errors.Is(err, d3d.ErrNoImageYet) {
 		// don't update
 		return nil, ErrNoFrame
@@ -113,9 +121,45 @@ func (d *display) nextFrame() ([]byte, error) {
 		return nil, err
 	}
 
+	if img != d.buf {
+		rotate(d.buf.Pix, d.width, d.height, d.raw.Pix, d.orientation)
+	}
+
 	return d.buf.Pix, nil
 }
 
+// rotate copies a frame in the orientation of the physical display to dst,
+// in the orientation of the desktop with the given size.
+func rotate(dst []uint8, width, height int, src []uint8, o video.Orientation) {
+	// size of the frame on the physical display
+	sw, sh := width, height
+	if o != video.LandscapeFlipped {
+		sw, sh = height, width
+	}
+
+	i := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sx, sy int
+
+			switch o {
+			case video.Portrait:
+				sx, sy = y, sh-1-x
+			case video.LandscapeFlipped:
+				sx, sy = sw-1-x, sh-1-y
+			case video.PortraitFlipped:
+				sx, sy = sw-1-y, x
+			default:
+				sx, sy = x, y
+			}
+
+			j := (sy*sw + sx) * 4
+			copy(dst[i:i+4], src[j:j+4])
+			i += 4
+		}
+	}
+}
+
 func (d *display) reset() error {
 	_ = d.Close()
 
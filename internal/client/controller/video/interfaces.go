@@ -17,15 +17,22 @@ type Display interface {
 	Resolution() string
 	String() string
 	Close() error
+	// Capture returns frames of Width by Height pixels, as the desktop is
+	// shown on the display.
 	Capture(ctx context.Context, framerate int) chan []byte
+	// Orientation is how the desktop is rotated on the physical display.
 	Orientation() Orientation
 }
 
+// Orientation is the rotation of the desktop relative to the physical display.
 type Orientation int
 
 const (
 	Landscape Orientation = iota
+	// Portrait rotates the desktop 90 degrees clockwise, so that the left
+	// edge of the physical display is at the top.
 	Portrait
 	LandscapeFlipped
+	// PortraitFlipped rotates the desktop 90 degrees counter-clockwise.
 	PortraitFlipped
 )
@@ -1,5 +1,7 @@
 package video
 
+import "errors"
+
 type Option func(p *Visualizer) error
 
 func WithLedsCount(leds int) Option {
@@ -22,3 +24,16 @@ func WithDisplayConfig(cfg [][]DisplayConfig) Option {
 		return nil
 	}
 }
+
+// WithWorkers sets the amount of segments that are sampled in parallel. It
+// defaults to the amount of CPUs.
+func WithWorkers(workers int) Option {
+	return func(p *Visualizer) error {
+		if workers < 1 {
+			return errors.New("invalid worker count")
+		}
+
+		p.workers = workers
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package video
+
+import (
+	"image"
+
+	"ledctl3/pkg/layout"
+)
+
+// rotated reports whether the orientation swaps the width and height of the
+// display.
+func (o Orientation) rotated() bool {
+	return o == Portrait || o == PortraitFlipped
+}
+
+// physical returns the size of the physical display, before the desktop was
+// rotated onto it, given the size of the desktop.
+func (o Orientation) physical(width, height int) (int, int) {
+	if o.rotated() {
+		return height, width
+	}
+
+	return width, height
+}
+
+// point maps a point on the physical display with the given size to the
+// desktop.
+func (o Orientation) point(x, y, width, height float64) (float64, float64) {
+	switch o {
+	case Portrait:
+		return height - y, x
+	case LandscapeFlipped:
+		return width - x, height - y
+	case PortraitFlipped:
+		return y, width - x
+	default:
+		return x, y
+	}
+}
+
+// rect maps a rectangle on the physical display with the given size to the
+// desktop.
+func (o Orientation) rect(r image.Rectangle, width, height int) image.Rectangle {
+	w, h := float64(width), float64(height)
+
+	x0, y0 := o.point(float64(r.Min.X), float64(r.Min.Y), w, h)
+	x1, y1 := o.point(float64(r.Max.X), float64(r.Max.Y), w, h)
+
+	return image.Rect(int(x0), int(y0), int(x1), int(y1))
+}
+
+// region maps a region of a layout, relative to the physical display, to the
+// desktop.
+func (o Orientation) region(r layout.Rect) layout.Rect {
+	x0, y0 := o.point(r.X0, r.Y0, 1, 1)
+	x1, y1 := o.point(r.X1, r.Y1, 1, 1)
+
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+
+	return layout.Rect{X0: x0, Y0: y0, X1: x1, Y1: y1}
+}
+
+// flips reports whether LEDs that run along the horizontal or vertical axis
+// of the physical display run the other way on the desktop.
+func (o Orientation) flips(vertical bool) bool {
+	switch o {
+	case Portrait:
+		return vertical
+	case LandscapeFlipped:
+		return true
+	case PortraitFlipped:
+		return !vertical
+	default:
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"ledctl3/pkg/layout"
+)
+
+func TestOrientation(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	green := color.RGBA{G: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+
+	// the physical display has red and green halves along its top edge and
+	// blue at the bottom of its left edge
+	const w, h = 40, 20
+	physical := newImage(w, h, func(x, y int) color.RGBA {
+		switch {
+		case y < 4 && x < w/2:
+			return red
+		case y < 4:
+			return green
+		case x < 4 && y >= h/2:
+			return blue
+		}
+
+		return color.RGBA{A: 0xff}
+	})
+
+	// desktop returns the frame as captured with the desktop rotated
+	desktop := func(o Orientation) *image.RGBA {
+		dw, dh := w, h
+		if o.rotated() {
+			dw, dh = h, w
+		}
+
+		return newImage(dw, dh, func(x, y int) color.RGBA {
+			switch o {
+			case Portrait:
+				return physical.RGBAAt(y, h-1-x)
+			case LandscapeFlipped:
+				return physical.RGBAAt(w-1-x, h-1-y)
+			case PortraitFlipped:
+				return physical.RGBAAt(w-1-y, x)
+			default:
+				return physical.RGBAAt(x, y)
+			}
+		})
+	}
+
+	cfg := DisplayConfig{
+		Width:  w,
+		Height: h,
+		Segments: []Segment{
+			{Id: 0, Leds: 2, From: Vector2{0, 0}, To: Vector2{w, 4}},
+			{Id: 1, Leds: 2, From: Vector2{0, 0}, To: Vector2{4, h}, Reverse: true},
+			{Id: 2, Leds: 2, Regions: []layout.Rect{
+				{X0: 0, Y0: 0, X1: 0.1, Y1: 0.2},
+				{X0: 0, Y0: 0.5, X1: 0.1, Y1: 1},
+			}},
+		},
+	}
+
+	for _, o := range []Orientation{Landscape, Portrait, LandscapeFlipped, PortraitFlipped} {
+		img := desktop(o)
+
+		p, err := newPipeline(&fakeDisplay{width: img.Rect.Dx(), height: img.Rect.Dy(), orientation: o}, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.src = *img
+
+		// the LEDs sample the same colors whichever way the desktop is
+		// rotated
+		for _, seg := range p.segments {
+			seg.sample(&p.src, p.proc, make([]uint8, seg.Leds*6), time.Now())
+		}
+
+		assertValues(t, []float64{1, 0, 0, 0, 1, 0}, p.segments[0].values)
+		// reversed, starting at the bottom, and partly red at the top
+		assertValues(t, []float64{0, 0, 1, 0.4, 0, 0}, p.segments[1].values)
+		assertValues(t, []float64{1, 0, 0, 0, 0, 1}, p.segments[2].values)
+	}
+}
+
+func TestMatchRotatedDisplay(t *testing.T) {
+	v, err := New(
+		WithDisplayRepository(fakeRepository{}),
+		WithDisplayConfig([][]DisplayConfig{{{Id: 0, Width: 1920, Height: 1080, Framerate: 60}}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rotated display matches the config of the physical display
+	match, err := v.matchDisplays([]Display{&fakeDisplay{width: 1080, height: 1920, orientation: Portrait}})
+	if err != nil || len(match) != 1 {
+		t.Fatalf("expected the rotated display to match, got %v, %v", match, err)
+	}
+
+	_, err = v.matchDisplays([]Display{&fakeDisplay{width: 1080, height: 1920, orientation: Landscape}})
+	if err != ErrConfigNotFound {
+		t.Fatalf("expected no match, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"ledctl3/internal/client/visualizer"
+	"ledctl3/pkg/filter"
+	"ledctl3/pkg/layout"
+)
+
+// outputs is the amount of update events of a display that can be in flight
+// at once. Frames wait for an event to be released once all are in use.
+const outputs = 3
+
+// pipeline turns the frames of a display into update events. All of its
+// buffers are allocated up front and reused for every frame, and its segments
+// are sampled by the worker pool of the visualizer.
+type pipeline struct {
+	cfg      DisplayConfig
+	src      image.RGBA
+	segments []*segment
+	wg       sync.WaitGroup
+
+	// bars detects black bars if enabled. The segments sample the crop, the
+	// active picture area of the frames.
+	bars    *barDetector
+	cropMux sync.Mutex
+	crop    image.Rectangle
+
+	// proc processes the colors of the current frame. Changes are picked up
+	// from pending at the start of the next frame.
+	proc    *processor
+	procMux sync.Mutex
+	pending *processor
+
+	// free holds the outputs that aren't in use by an update event.
+	free chan *output
+}
+
+// segment holds the sampling state of a segment.
+type segment struct {
+	Segment
+
+	sampler ledSampler
+
+	// rect and regions are the sampling areas of the segment on the desktop,
+	// rotated from the physical display. reverse reverses the order of the
+	// LEDs if the rotation flipped it.
+	rect    image.Rectangle
+	regions []layout.Rect
+	reverse bool
+
+	// values holds the RGB channels of each LED, in the range 0 to 1, in the
+	// order of the strip.
+	values []float64
+	filter filter.Filter
+}
+
+// output holds the pixels of the segments for an update event.
+type output struct {
+	segs    []visualizer.Segment
+	release func()
+}
+
+// job samples a segment of a frame into pix.
+type job struct {
+	seg  *segment
+	src  *image.RGBA
+	pix  []uint8
+	proc *processor
+	now  time.Time
+	wg   *sync.WaitGroup
+}
+
+func newPipeline(d Display, cfg DisplayConfig) (*pipeline, error) {
+	p := &pipeline{
+		cfg: cfg,
+		src: image.RGBA{
+			Stride: d.Width() * 4,
+			Rect:   image.Rect(0, 0, d.Width(), d.Height()),
+		},
+		free: make(chan *output, outputs),
+	}
+
+	p.crop = p.src.Rect
+	p.proc = newProcessor(cfg.Processing)
+
+	if cfg.DetectBars {
+		p.bars = newBarDetector(p.src.Rect)
+	}
+
+	// segments are placed on the physical display, which the desktop may be
+	// rotated on
+	o := d.Orientation()
+	width, height := o.physical(d.Width(), d.Height())
+
+	for _, s := range cfg.Segments {
+		if len(s.Regions) > 0 && len(s.Regions) != s.Leds {
+			return nil, fmt.Errorf("segment %d has %d LEDs but %d regions", s.Id, s.Leds, len(s.Regions))
+		}
+
+		rect := image.Rect(s.From.X, s.From.Y, s.To.X, s.To.Y)
+
+		seg := &segment{
+			Segment: s,
+			rect:    o.rect(rect, width, height),
+			reverse: s.Reverse != o.flips(rect.Dx() < rect.Dy()),
+			values:  make([]float64, s.Leds*3),
+		}
+
+		for _, r := range s.Regions {
+			seg.regions = append(seg.regions, o.region(r))
+		}
+
+		if len(seg.regions) > 0 {
+			// regions keep the order of the strip
+			seg.reverse = s.Reverse
+		}
+
+		var err error
+		seg.filter, err = filter.New(cfg.Smoothing.filter(s.Filter))
+		if err != nil {
+			return nil, err
+		}
+
+		p.segments = append(p.segments, seg)
+	}
+
+	for i := 0; i < outputs; i++ {
+		out := &output{
+			segs: make([]visualizer.Segment, len(p.segments)),
+		}
+
+		for j, seg := range p.segments {
+			out.segs[j] = visualizer.Segment{
+				Id:  seg.Id,
+				Pix: make([]uint8, seg.Leds*6),
+			}
+		}
+
+		out.release = func() {
+			p.free <- out
+		}
+
+		p.free <- out
+	}
+
+	p.setCrop(p.crop)
+
+	return p, nil
+}
+
+// setCrop moves the sampling areas of the segments into the crop.
+func (p *pipeline) setCrop(crop image.Rectangle) {
+	for _, seg := range p.segments {
+		if len(seg.regions) > 0 {
+			seg.sampler = newRegionSampler(seg.regions, crop)
+			continue
+		}
+
+		rect := cropRect(seg.rect, p.src.Rect, crop)
+
+		seg.sampler = newSampler(rect, crop, seg.Leds, seg.Sampling)
+	}
+
+	p.cropMux.Lock()
+	p.crop = crop
+	p.cropMux.Unlock()
+}
+
+// setProcessing changes the color processing from the next frame on.
+func (p *pipeline) setProcessing(proc *processor) {
+	p.procMux.Lock()
+	p.pending = proc
+	p.procMux.Unlock()
+}
+
+// Crop returns the active picture area the segments sample.
+func (p *pipeline) Crop() image.Rectangle {
+	p.cropMux.Lock()
+	defer p.cropMux.Unlock()
+
+	return p.crop
+}
+
+// process samples all segments of a frame with the worker pool and returns an
+// update event. The event must be released once its pixels have been
+// consumed.
+func (p *pipeline) process(ctx context.Context, jobs chan<- job, pix []byte) (visualizer.UpdateEvent, error) {
+	now := time.Now()
+
+	var out *output
+
+	select {
+	case out = <-p.free:
+	case <-ctx.Done():
+		return visualizer.UpdateEvent{}, ctx.Err()
+	}
+
+	p.src.Pix = pix
+
+	p.procMux.Lock()
+	if p.pending != nil {
+		p.proc, p.pending = p.pending, nil
+	}
+	p.procMux.Unlock()
+
+	if p.bars != nil && p.bars.update(&p.src, now) {
+		p.setCrop(p.bars.crop)
+	}
+
+	p.wg.Add(len(p.segments))
+
+	for i, seg := range p.segments {
+		jobs <- job{
+			seg:  seg,
+			src:  &p.src,
+			pix:  out.segs[i].Pix,
+			proc: p.proc,
+			now:  now,
+			wg:   &p.wg,
+		}
+	}
+
+	p.wg.Wait()
+
+	return visualizer.UpdateEvent{
+		Segments: out.segs,
+		Latency:  time.Since(now),
+		Release:  out.release,
+	}, nil
+}
+
+// work processes jobs until the channel is closed.
+func work(jobs <-chan job) {
+	for j := range jobs {
+		j.seg.sample(j.src, j.proc, j.pix, j.now)
+		j.wg.Done()
+	}
+}
+
+// sample averages the pixels of each LED, processes and filters the colors
+// and writes them to pix as 16-bit RGB.
+func (seg *segment) sample(src *image.RGBA, proc *processor, pix []uint8, now time.Time) {
+	seg.sampler.sample(src, seg.values)
+	proc.apply(seg.values)
+
+	if seg.reverse {
+		v := seg.values
+		for i, j := 0, len(v)-3; i < j; i, j = i+3, j-3 {
+			v[i], v[i+1], v[i+2], v[j], v[j+1], v[j+2] = v[j], v[j+1], v[j+2], v[i], v[i+1], v[i+2]
+		}
+	}
+
+	seg.filter.Apply(seg.values, now)
+
+	for i, v := range seg.values {
+		c := encode(v)
+		pix[i*2] = uint8(c >> 8)
+		pix[i*2+1] = uint8(c)
+	}
+}
+
+func encode(v float64) uint16 {
+	if v <= 0 {
+		return 0
+	}
+
+	if v >= 1 {
+		return 0xffff
+	}
+
+	return uint16(v*0xffff + 0.5)
+}
@@ -0,0 +1,150 @@
+package video
+
+import (
+	"errors"
+	"math"
+
+	clr "ledctl3/pkg/color"
+)
+
+// neutralTemperature is the white point of sRGB, which leaves colors as they
+// are.
+const neutralTemperature = 6500
+
+// Processing adjusts the sampled colors of a display before they are sent.
+// Zero values leave the colors as they are.
+type Processing struct {
+	// Saturation scales the saturation of the colors, e.g. 1.5 makes them
+	// half again as saturated.
+	Saturation float64
+	// Red, Green and Blue are gains applied to each channel.
+	Red   float64
+	Green float64
+	Blue  float64
+	// Threshold turns colors black whose brightest channel is below it, in
+	// the range 0 to 1. It keeps dark scenes from lighting up the LEDs.
+	Threshold float64
+	// MaxBrightness limits the luminance of each LED, in the range 0 to 1.
+	MaxBrightness float64
+	// Temperature is the white balance in Kelvin. Temperatures below 6500K
+	// make colors warmer, higher ones cooler.
+	Temperature float64
+}
+
+// Validate checks that the settings are in range.
+func (p Processing) Validate() error {
+	if p.Saturation < 0 || p.Saturation > 4 {
+		return errors.New("saturation must be between 0 and 4")
+	}
+
+	if p.Red < 0 || p.Red > 4 || p.Green < 0 || p.Green > 4 || p.Blue < 0 || p.Blue > 4 {
+		return errors.New("channel gains must be between 0 and 4")
+	}
+
+	if p.Threshold < 0 || p.Threshold > 1 {
+		return errors.New("threshold must be between 0 and 1")
+	}
+
+	if p.MaxBrightness < 0 || p.MaxBrightness > 1 {
+		return errors.New("maximum brightness must be between 0 and 1")
+	}
+
+	if p.Temperature != 0 && (p.Temperature < 1000 || p.Temperature > 25000) {
+		return errors.New("temperature must be between 1000K and 25000K")
+	}
+
+	return nil
+}
+
+// processor applies processing in linear light. It is immutable, so that it
+// can be swapped between frames while workers use it.
+type processor struct {
+	neutral bool
+
+	saturation float64
+	gain       [3]float64
+	threshold  float64
+	limit      float64
+}
+
+func newProcessor(p Processing) *processor {
+	pr := &processor{
+		saturation: or(p.Saturation, 1),
+		gain:       [3]float64{or(p.Red, 1), or(p.Green, 1), or(p.Blue, 1)},
+		threshold:  p.Threshold,
+		limit:      or(p.MaxBrightness, 1),
+	}
+
+	// white balance relative to the white point of sRGB, without raising any
+	// channel
+	if t := or(p.Temperature, neutralTemperature); t != neutralTemperature {
+		r, g, b := clr.TemperatureLinear(t)
+		nr, ng, nb := clr.TemperatureLinear(neutralTemperature)
+
+		wb := [3]float64{r / nr, g / ng, b / nb}
+		m := math.Max(wb[0], math.Max(wb[1], wb[2]))
+
+		for i := range pr.gain {
+			pr.gain[i] *= wb[i] / m
+		}
+	}
+
+	pr.neutral = pr.saturation == 1 && pr.gain == [3]float64{1, 1, 1} && pr.threshold == 0 && pr.limit == 1
+
+	return pr
+}
+
+// apply processes RGB values in the range 0 to 1, in place.
+func (pr *processor) apply(values []float64) {
+	if pr.neutral {
+		return
+	}
+
+	for i := 0; i+2 < len(values); i += 3 {
+		c := values[i : i+3 : i+3]
+
+		if math.Max(c[0], math.Max(c[1], c[2])) < pr.threshold {
+			c[0], c[1], c[2] = 0, 0, 0
+			continue
+		}
+
+		r := clr.SRGBToLinear(c[0]) * pr.gain[0]
+		g := clr.SRGBToLinear(c[1]) * pr.gain[1]
+		b := clr.SRGBToLinear(c[2]) * pr.gain[2]
+
+		if pr.saturation != 1 {
+			y := luminance(r, g, b)
+			r = math.Max(0, y+(r-y)*pr.saturation)
+			g = math.Max(0, y+(g-y)*pr.saturation)
+			b = math.Max(0, y+(b-y)*pr.saturation)
+		}
+
+		// scale channels that overflow down together to keep the hue
+		if m := math.Max(r, math.Max(g, b)); m > 1 {
+			r, g, b = r/m, g/m, b/m
+		}
+
+		if y := luminance(r, g, b); y > pr.limit {
+			s := pr.limit / y
+			r, g, b = r*s, g*s, b*s
+		}
+
+		c[0] = clr.LinearToSRGB(r)
+		c[1] = clr.LinearToSRGB(g)
+		c[2] = clr.LinearToSRGB(b)
+	}
+}
+
+// luminance returns the relative luminance of linear sRGB.
+func luminance(r, g, b float64) float64 {
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// or returns v, or def if v is zero.
+func or(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+
+	return v
+}
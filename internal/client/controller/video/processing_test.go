@@ -0,0 +1,107 @@
+package video
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"ledctl3/pkg/filter"
+)
+
+func TestProcessing(t *testing.T) {
+	process := func(p Processing, values ...float64) []float64 {
+		newProcessor(p).apply(values)
+		return values
+	}
+
+	// zero values leave colors as they are
+	assertValues(t, []float64{0.2, 0.4, 0.6}, process(Processing{}, 0.2, 0.4, 0.6))
+	assertValues(t, []float64{0.2, 0.4, 0.6}, process(Processing{Temperature: 6500}, 0.2, 0.4, 0.6))
+
+	// dim colors are cut off
+	assertValues(t, []float64{0, 0, 0, 0.2, 0, 0}, process(Processing{Threshold: 0.1}, 0.05, 0.02, 0, 0.2, 0, 0))
+
+	// gains apply in linear light, half the light isn't half the value
+	v := process(Processing{Red: 0.5}, 1, 1, 1)
+	assertValues(t, []float64{0.7354, 1, 1}, v)
+
+	// boosting saturation moves channels away from gray
+	v = process(Processing{Saturation: 2}, 0.6, 0.5, 0.5)
+	if v[0] <= 0.6 || v[1] >= 0.5 {
+		t.Fatalf("expected a more saturated color, got %v", v)
+	}
+
+	// saturation can't overflow, the channels scale down together
+	v = process(Processing{Saturation: 4}, 1, 0.5, 0.5)
+	assertValues(t, []float64{1, 0, 0}, v)
+
+	// white is limited to the maximum luminance
+	v = process(Processing{MaxBrightness: 0.5}, 1, 1, 1)
+	assertValues(t, []float64{0.7354, 0.7354, 0.7354}, v)
+
+	// warm white balance lowers blue and keeps red
+	v = process(Processing{Temperature: 3000}, 1, 1, 1)
+	if v[0] < 0.999 || v[2] >= v[1] || v[1] >= 1 {
+		t.Fatalf("expected a warmer white, got %v", v)
+	}
+
+	for _, p := range []Processing{
+		{Saturation: -1},
+		{Green: 5},
+		{Threshold: 2},
+		{MaxBrightness: 1.5},
+		{Temperature: 500},
+	} {
+		if p.Validate() == nil {
+			t.Fatalf("expected %+v to be invalid", p)
+		}
+	}
+}
+
+func TestSmoothing(t *testing.T) {
+	p, err := newPipeline(&fakeDisplay{width: 40, height: 10}, DisplayConfig{
+		Width:     40,
+		Height:    10,
+		Smoothing: Smoothing{Strength: 0.8},
+		Segments: []Segment{
+			{Id: 0, Leds: 1, From: Vector2{0, 0}, To: Vector2{40, 10}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seg := p.segments[0]
+	pix := make([]uint8, 6)
+	now := time.Now()
+
+	frame := func(v uint8) {
+		now = now.Add(16 * time.Millisecond)
+		p.src = *newImage(40, 10, func(x, y int) color.RGBA {
+			return color.RGBA{R: v, G: v, B: v, A: 0xff}
+		})
+		seg.sample(&p.src, p.proc, pix, now)
+	}
+
+	frame(0x80)
+
+	// small changes are smoothed
+	frame(0x90)
+	if seg.values[0] >= float64(0x88)/0xff {
+		t.Fatalf("expected a smoothed change, got %v", seg.values)
+	}
+
+	// scene cuts are not
+	frame(0xff)
+	assertValues(t, []float64{1, 1, 1}, seg.values)
+
+	// neither are they with a filter of the segment's own and no strength
+	spec := Smoothing{}.filter(filter.Spec{Type: filter.EWMA, Window: 10})
+	if spec.Cut != defaultCut {
+		t.Fatalf("expected the default cut, got %v", spec.Cut)
+	}
+
+	if spec = (Smoothing{}).filter(filter.Spec{}); spec.Type != "" {
+		t.Fatalf("expected no filter without strength, got %+v", spec)
+	}
+}
@@ -0,0 +1,85 @@
+package video
+
+import (
+	"image"
+	"math"
+
+	"ledctl3/pkg/layout"
+)
+
+// regionSampler averages an arbitrary area of the screen per LED, for
+// segments placed by a layout.
+type regionSampler struct {
+	// rects holds the pixels sampled by each LED. LEDs with empty rectangles
+	// stay black.
+	rects []image.Rectangle
+}
+
+// newRegionSampler maps the regions of a layout, relative to the screen, to
+// the pixels of bounds.
+func newRegionSampler(regions []layout.Rect, bounds image.Rectangle) *regionSampler {
+	s := &regionSampler{
+		rects: make([]image.Rectangle, len(regions)),
+	}
+
+	scale := func(v float64, min, size int) int {
+		return min + int(math.Round(v*float64(size)))
+	}
+
+	for i, r := range regions {
+		if r.Empty() {
+			continue
+		}
+
+		rect := image.Rect(
+			scale(r.X0, bounds.Min.X, bounds.Dx()),
+			scale(r.Y0, bounds.Min.Y, bounds.Dy()),
+			scale(r.X1, bounds.Min.X, bounds.Dx()),
+			scale(r.Y1, bounds.Min.Y, bounds.Dy()),
+		)
+
+		// regions smaller than a pixel still sample one
+		if rect.Dx() == 0 {
+			rect.Max.X = min(rect.Min.X+1, bounds.Max.X)
+			rect.Min.X = rect.Max.X - 1
+		}
+		if rect.Dy() == 0 {
+			rect.Max.Y = min(rect.Min.Y+1, bounds.Max.Y)
+			rect.Min.Y = rect.Max.Y - 1
+		}
+
+		s.rects[i] = rect.Intersect(bounds)
+	}
+
+	return s
+}
+
+// sample writes the average RGB channels of each LED to values, in the range
+// 0 to 1.
+func (s *regionSampler) sample(src *image.RGBA, values []float64) {
+	for i, rect := range s.rects {
+		if rect.Empty() {
+			values[i*3], values[i*3+1], values[i*3+2] = 0, 0, 0
+			continue
+		}
+
+		var r, g, b uint64
+
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			off := src.PixOffset(rect.Min.X, y)
+			end := off + rect.Dx()*4
+
+			for ; off < end; off += 4 {
+				r += uint64(src.Pix[off])
+				g += uint64(src.Pix[off+1])
+				b += uint64(src.Pix[off+2])
+			}
+		}
+
+		n := float64(rect.Dx()*rect.Dy()) * 0xff
+
+		values[i*3] = float64(r) / n
+		values[i*3+1] = float64(g) / n
+		values[i*3+2] = float64(b) / n
+	}
+}
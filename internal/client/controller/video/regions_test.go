@@ -0,0 +1,55 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"ledctl3/pkg/layout"
+)
+
+func TestRegionSampler(t *testing.T) {
+	// red on the left half, blue on the right
+	img := newImage(40, 20, func(x, y int) color.RGBA {
+		if x < 20 {
+			return color.RGBA{R: 0xff, A: 0xff}
+		}
+
+		return color.RGBA{B: 0xff, A: 0xff}
+	})
+
+	s := newRegionSampler([]layout.Rect{
+		{X0: 0, Y0: 0, X1: 0.25, Y1: 1},
+		{},
+		{X0: 0.25, Y0: 0.5, X1: 0.75, Y1: 1},
+		{X0: 0.9, Y0: 0.9, X1: 0.9, Y1: 0.9},
+	}, img.Rect)
+
+	values := make([]float64, 4*3)
+	s.sample(img, values)
+
+	assertValues(t, []float64{
+		1, 0, 0,
+		0, 0, 0,
+		0.5, 0, 0.5,
+		0, 0, 0,
+	}, values)
+
+	// regions follow the crop
+	s = newRegionSampler([]layout.Rect{{X0: 0, Y0: 0, X1: 0.5, Y1: 1}}, image.Rect(20, 0, 40, 20))
+	s.sample(img, values)
+	assertValues(t, []float64{0, 0, 1}, values)
+}
+
+func TestRegionsMismatch(t *testing.T) {
+	_, err := newPipeline(&fakeDisplay{width: 40, height: 20}, DisplayConfig{
+		Width:  40,
+		Height: 20,
+		Segments: []Segment{
+			{Id: 0, Leds: 2, Regions: []layout.Rect{{X0: 0, Y0: 0, X1: 1, Y1: 1}}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a segment with too few regions")
+	}
+}
@@ -0,0 +1,206 @@
+package video
+
+import (
+	"image"
+	"math"
+)
+
+// Sampling configures how a segment samples the edge of the screen. Zero
+// values sample the whole segment rectangle evenly.
+type Sampling struct {
+	// Depth is how far into the screen the LEDs sample, in pixels from the
+	// edge. DepthPercent is the same as a percentage of the screen height for
+	// horizontal segments, or of its width for vertical ones. Depth takes
+	// precedence if both are set.
+	Depth        int
+	DepthPercent float64
+	// EdgeWeight weighs pixels close to the edge more than those further
+	// inside. At 0 all pixels are weighed equally, at 1 the weight falls off
+	// linearly to nothing at the full depth.
+	EdgeWeight float64
+	// Overlap widens the box of each LED into its neighbours by a fraction of
+	// its width on both sides, which softens the borders between LEDs.
+	Overlap float64
+}
+
+// ledSampler writes the average RGB channels of each LED of a segment to
+// values, in the range 0 to 1.
+type ledSampler interface {
+	sample(src *image.RGBA, values []float64)
+}
+
+// weightScale is the weight of the pixels right at the edge.
+const weightScale = 256
+
+// sampler averages a box of pixels per LED along an edge of the screen. Unlike
+// a bilinear scaler, every pixel in the box contributes, so fine detail doesn't
+// alias into flicker.
+type sampler struct {
+	vertical bool
+	// rect is the area that is sampled.
+	rect image.Rectangle
+	// weights holds the weight of each line of pixels parallel to the edge,
+	// in the order of rect.
+	weights []uint32
+	total   uint64
+	// boxes holds the start and end of each LED along the edge, relative to
+	// rect.
+	boxes [][2]int
+	// prefix holds the running sums of the weighted RGB channels along the
+	// edge.
+	prefix []uint64
+}
+
+// newSampler creates a sampler for the LEDs of a segment that spans rect on
+// a display with the given bounds.
+func newSampler(rect, bounds image.Rectangle, leds int, opts Sampling) *sampler {
+	s := &sampler{
+		vertical: rect.Dx() < rect.Dy(),
+	}
+
+	// the edge is on the side of the screen the segment is closest to, and
+	// the depth extends from it
+	depth, size := rect.Dy(), bounds.Dy()
+	near := rect.Min.Y+rect.Max.Y < bounds.Min.Y+bounds.Max.Y
+	if s.vertical {
+		depth, size = rect.Dx(), bounds.Dx()
+		near = rect.Min.X+rect.Max.X < bounds.Min.X+bounds.Max.X
+	}
+
+	switch {
+	case opts.Depth > 0:
+		depth = opts.Depth
+	case opts.DepthPercent > 0:
+		depth = int(math.Round(float64(size) * opts.DepthPercent / 100))
+	}
+
+	depth = max(1, depth)
+
+	if s.vertical {
+		if near {
+			rect.Max.X = rect.Min.X + depth
+		} else {
+			rect.Min.X = rect.Max.X - depth
+		}
+	} else {
+		if near {
+			rect.Max.Y = rect.Min.Y + depth
+		} else {
+			rect.Min.Y = rect.Max.Y - depth
+		}
+	}
+
+	s.rect = rect.Intersect(bounds)
+
+	length, lines := s.rect.Dx(), s.rect.Dy()
+	if s.vertical {
+		length, lines = lines, length
+	}
+
+	s.weights = make([]uint32, lines)
+	for i := range s.weights {
+		// distance from the edge
+		d := i
+		if !near {
+			d = lines - 1 - i
+		}
+
+		w := 1 - opts.EdgeWeight*float64(d)/float64(depth)
+		s.weights[i] = uint32(max(1, int(math.Round(w*weightScale))))
+		s.total += uint64(s.weights[i])
+	}
+
+	s.boxes = make([][2]int, leds)
+	width := float64(length) / float64(leds)
+
+	for i := range s.boxes {
+		start := (float64(i) - opts.Overlap) * width
+		end := (float64(i+1) + opts.Overlap) * width
+
+		a := min(max(0, int(math.Round(start))), length-1)
+		b := min(max(a+1, int(math.Round(end))), length)
+
+		s.boxes[i] = [2]int{a, b}
+	}
+
+	s.prefix = make([]uint64, (length+1)*3)
+
+	return s
+}
+
+// sample writes the average RGB channels of each LED to values, in the range
+// 0 to 1.
+func (s *sampler) sample(src *image.RGBA, values []float64) {
+	if s.rect.Empty() {
+		for i := range values {
+			values[i] = 0
+		}
+
+		return
+	}
+
+	p := s.prefix
+	for i := range p {
+		p[i] = 0
+	}
+
+	if s.vertical {
+		// each line of the frame is a position along the edge
+		for y := s.rect.Min.Y; y < s.rect.Max.Y; y++ {
+			var r, g, b uint64
+
+			off := src.PixOffset(s.rect.Min.X, y)
+			for _, w := range s.weights {
+				r += uint64(src.Pix[off]) * uint64(w)
+				g += uint64(src.Pix[off+1]) * uint64(w)
+				b += uint64(src.Pix[off+2]) * uint64(w)
+				off += 4
+			}
+
+			i := (y - s.rect.Min.Y + 1) * 3
+			p[i], p[i+1], p[i+2] = p[i-3]+r, p[i-2]+g, p[i-1]+b
+		}
+	} else {
+		// accumulate each line of the frame into the columns, then sum them up
+		for y := s.rect.Min.Y; y < s.rect.Max.Y; y++ {
+			w := uint64(s.weights[y-s.rect.Min.Y])
+			off := src.PixOffset(s.rect.Min.X, y)
+
+			for i := 3; i < len(p); i += 3 {
+				p[i] += uint64(src.Pix[off]) * w
+				p[i+1] += uint64(src.Pix[off+1]) * w
+				p[i+2] += uint64(src.Pix[off+2]) * w
+				off += 4
+			}
+		}
+
+		for i := 3; i < len(p); i++ {
+			p[i] += p[i-3]
+		}
+	}
+
+	for i, box := range s.boxes {
+		a, b := box[0]*3, box[1]*3
+		n := float64(s.total) * float64(box[1]-box[0]) * 0xff
+
+		values[i*3] = float64(p[b]-p[a]) / n
+		values[i*3+1] = float64(p[b+1]-p[a+1]) / n
+		values[i*3+2] = float64(p[b+2]-p[a+2]) / n
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
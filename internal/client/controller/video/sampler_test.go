@@ -0,0 +1,110 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func newImage(width, height int, fn func(x, y int) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, fn(x, y))
+		}
+	}
+
+	return img
+}
+
+func assertValues(t *testing.T, expected, values []float64) {
+	t.Helper()
+
+	for i := range expected {
+		if math.Abs(expected[i]-values[i]) > 1e-3 {
+			t.Fatalf("expected %v, got %v", expected, values)
+		}
+	}
+}
+
+func TestSamplerAverage(t *testing.T) {
+	// single pixel stripes alias with a bilinear scaler, averaging them gives
+	// an even gray
+	img := newImage(40, 20, func(x, y int) color.RGBA {
+		if x%2 == 0 {
+			return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+		}
+
+		return color.RGBA{A: 0xff}
+	})
+
+	s := newSampler(image.Rect(0, 0, 40, 4), img.Rect, 4, Sampling{})
+
+	values := make([]float64, 4*3)
+	s.sample(img, values)
+
+	for _, v := range values {
+		if v != 0.5 {
+			t.Fatalf("expected an even gray, got %v", values)
+		}
+	}
+}
+
+func TestSamplerDepth(t *testing.T) {
+	// red rows near the top, green near the bottom, blue columns near the
+	// right
+	img := newImage(100, 100, func(x, y int) color.RGBA {
+		switch {
+		case y < 10:
+			return color.RGBA{R: 0xff, A: 0xff}
+		case y >= 90:
+			return color.RGBA{G: 0xff, A: 0xff}
+		case x >= 95:
+			return color.RGBA{B: 0xff, A: 0xff}
+		}
+
+		return color.RGBA{A: 0xff}
+	})
+
+	values := make([]float64, 3)
+
+	// the top edge samples 10 rows down from the top, half of 20
+	newSampler(image.Rect(0, 0, 100, 20), img.Rect, 1, Sampling{DepthPercent: 10}).sample(img, values)
+	assertValues(t, []float64{1, 0, 0}, values)
+
+	// the bottom edge samples up from the bottom
+	newSampler(image.Rect(0, 80, 100, 100), img.Rect, 1, Sampling{Depth: 10}).sample(img, values)
+	assertValues(t, []float64{0, 1, 0}, values)
+
+	// the right edge samples 10 columns, half of them blue
+	newSampler(image.Rect(50, 20, 100, 80), img.Rect, 1, Sampling{Depth: 10}).sample(img, values)
+	assertValues(t, []float64{0, 0, 0.5}, values)
+
+	// weighing the edge makes the blue columns count for more
+	newSampler(image.Rect(50, 20, 100, 80), img.Rect, 1, Sampling{Depth: 10, EdgeWeight: 1}).sample(img, values)
+	if values[2] <= 0.5 {
+		t.Fatalf("expected the edge to weigh more, got %v", values)
+	}
+}
+
+func TestSamplerOverlap(t *testing.T) {
+	// the left half is white, the right half black
+	img := newImage(40, 4, func(x, y int) color.RGBA {
+		if x < 20 {
+			return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+		}
+
+		return color.RGBA{A: 0xff}
+	})
+
+	values := make([]float64, 4*3)
+
+	newSampler(img.Rect, img.Rect, 4, Sampling{}).sample(img, values)
+	assertValues(t, []float64{1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0}, values)
+
+	// boxes reach half an LED into their neighbours, and are cut at the ends
+	newSampler(img.Rect, img.Rect, 4, Sampling{Overlap: 0.5}).sample(img, values)
+	assertValues(t, []float64{1, 1, 1, 0.75, 0.75, 0.75, 0.25, 0.25, 0.25, 0, 0, 0}, values)
+}
@@ -0,0 +1,55 @@
+package video
+
+import (
+	"errors"
+	"math"
+
+	"ledctl3/pkg/filter"
+)
+
+// defaultCut is the average change of the values of a segment between two
+// frames above which it counts as a scene cut.
+const defaultCut = 0.2
+
+// Smoothing smooths the colors of the segments of a display over time, except
+// on scene cuts, where they follow the picture at once.
+type Smoothing struct {
+	// Strength is how much colors are smoothed, from 0 for not at all to 1
+	// for heavily. It only applies to segments without a filter of their own.
+	Strength float64
+	// Cut is the average change of the colors of a segment between frames,
+	// from 0 to 1, above which the smoothing snaps to the new colors. It
+	// defaults to 0.2.
+	Cut float64
+}
+
+// Validate checks that the settings are in range.
+func (s Smoothing) Validate() error {
+	if s.Strength < 0 || s.Strength > 1 {
+		return errors.New("smoothing strength must be between 0 and 1")
+	}
+
+	if s.Cut < 0 || s.Cut > 1 {
+		return errors.New("scene cut threshold must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// filter returns the spec of the filter for a segment. Segments without a
+// filter of their own get a One-Euro filter whose cutoff drops from 10Hz to
+// 0.1Hz with the strength. Either way the filter snaps on scene cuts.
+func (s Smoothing) filter(spec filter.Spec) filter.Spec {
+	if s.Strength > 0 && (spec.Type == "" || spec.Type == filter.None) {
+		spec = filter.Spec{
+			Type:      filter.OneEuro,
+			MinCutoff: 0.1 * math.Pow(100, 1-s.Strength),
+		}
+	}
+
+	if spec.Cut == 0 {
+		spec.Cut = or(s.Cut, defaultCut)
+	}
+
+	return spec
+}
@@ -5,15 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"image"
-	"image/color"
+	"runtime"
 	"sync"
 	"time"
 
 	"ledctl3/internal/client/visualizer"
-
-	"golang.org/x/image/draw"
-
-	"github.com/lucasb-eyer/go-colorful"
+	"ledctl3/pkg/filter"
+	"ledctl3/pkg/layout"
 )
 
 var (
@@ -29,9 +27,42 @@ type Visualizer struct {
 	displayConfigs [][]DisplayConfig
 
 	displays []Display
-	scalers  map[int]draw.Scaler
+	// workers is the size of the pool that samples segments.
+	workers int
+
+	pipelineMux sync.Mutex
+	pipelines   map[int]*pipeline
+	// processing holds the color processing changed at runtime, by display
+	// id. It takes precedence over the display config.
+	processing map[int]Processing
+}
+
+type Statistics struct {
+	// Crops holds the active picture area the segments of each display
+	// sample, by display id. It only differs from the display bounds if
+	// black bar detection is enabled and found bars.
+	Crops map[int]image.Rectangle
+}
+
+func (v *Visualizer) Statistics() Statistics {
+	v.pipelineMux.Lock()
+	defer v.pipelineMux.Unlock()
+
+	stats := Statistics{
+		Crops: make(map[int]image.Rectangle, len(v.pipelines)),
+	}
+
+	for id, p := range v.pipelines {
+		stats.Crops[id] = p.Crop()
+	}
+
+	return stats
 }
 
+// DisplayConfig describes a display as it is physically mounted. Width and
+// Height are its resolution without rotation, and the segments are placed
+// relative to its physical edges, so that they stay on the same LEDs when the
+// desktop is rotated.
 type DisplayConfig struct {
 	Id        int
 	Width     int
@@ -40,6 +71,13 @@ type DisplayConfig struct {
 	Top       int
 	Framerate int
 	Segments  []Segment
+	// DetectBars moves the sampling areas of the segments inward to the
+	// picture when letterboxed or pillarboxed video is shown.
+	DetectBars bool
+	// Processing adjusts the colors sampled from the display.
+	Processing Processing
+	// Smoothing smooths the colors of the segments over time.
+	Smoothing Smoothing
 }
 
 type Segment struct {
@@ -48,6 +86,13 @@ type Segment struct {
 	From    Vector2
 	To      Vector2
 	Reverse bool
+	Filter  filter.Spec
+	// Sampling configures the area each LED averages.
+	Sampling Sampling
+	// Regions places each LED on the screen, relative to its size, instead of
+	// spreading them evenly between From and To. It must have an entry per
+	// LED.
+	Regions []layout.Rect
 }
 
 type Vector2 struct {
@@ -82,44 +127,64 @@ func (v *Visualizer) startCapture(ctx context.Context) error {
 		return err
 	}
 
-	v.scalers = make(map[int]draw.Scaler)
+	pipelines := make(map[int]*pipeline, len(v.displays))
 
-	for _, cfg := range displayConfigs {
-		for _, seg := range cfg.Segments {
-			rect := image.Rect(seg.From.X, seg.From.Y, seg.To.X, seg.To.Y)
+	v.pipelineMux.Lock()
 
-			// TODO: only allow cube (Dx == Dy) if segment is only 1 led
-
-			var width, height int
+	for _, d := range v.displays {
+		cfg := displayConfigs[d.Id()]
 
-			if rect.Dx() > rect.Dy() {
-				// horizontal
-				width = seg.Leds
-				height = 2
-			} else {
-				// vertical
-				width = 2
-				height = seg.Leds
-			}
+		if proc, ok := v.processing[d.Id()]; ok {
+			cfg.Processing = proc
+		}
 
-			v.scalers[seg.Id] = draw.BiLinear.NewScaler(width, height, cfg.Width, cfg.Height)
+		pipelines[d.Id()], err = newPipeline(d, cfg)
+		if err != nil {
+			v.pipelineMux.Unlock()
+			return err
 		}
 	}
 
+	v.pipelines = pipelines
+	v.pipelineMux.Unlock()
+
+	// Frames of all displays share a bounded pool of workers. Each display
+	// processes its frames in order, so a slow frame holds back capturing
+	// instead of piling up.
+	jobs := make(chan job)
+
+	for i := 0; i < v.workers; i++ {
+		go work(jobs)
+	}
+
+	defer close(jobs)
+
 	var wg sync.WaitGroup
 	wg.Add(len(v.displays))
 
 	for _, d := range v.displays {
-		cfg := displayConfigs[d.Id()]
+		p := pipelines[d.Id()]
 
 		go func(d Display) {
 			defer wg.Done()
-			frames := d.Capture(captureCtx, cfg.Framerate)
+			frames := d.Capture(captureCtx, p.cfg.Framerate)
 
 			for frame := range frames {
-				//fmt.Println(d.Resolution())
+				if len(p.segments) == 0 {
+					continue
+				}
 
-				go v.process(d, cfg, frame)
+				evt, err := p.process(captureCtx, jobs, frame)
+				if err != nil {
+					// keep draining until the capturer closes the channel
+					continue
+				}
+
+				select {
+				case v.events <- evt:
+				case <-captureCtx.Done():
+					evt.Release()
+				}
 			}
 
 			cancel()
@@ -131,6 +196,30 @@ func (v *Visualizer) startCapture(ctx context.Context) error {
 	return nil
 }
 
+// SetProcessing changes the color processing of a display while capturing.
+// The change is kept when capturing restarts.
+func (v *Visualizer) SetProcessing(display int, p Processing) error {
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+
+	v.pipelineMux.Lock()
+	defer v.pipelineMux.Unlock()
+
+	if v.processing == nil {
+		v.processing = make(map[int]Processing)
+	}
+
+	v.processing[display] = p
+
+	if pl, ok := v.pipelines[display]; ok {
+		pl.setProcessing(newProcessor(p))
+	}
+
+	return nil
+}
+
 func (v *Visualizer) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	v.cancel = cancel
@@ -177,82 +266,6 @@ func (v *Visualizer) stopCapture() {
 	v.displays = nil
 }
 
-func (v *Visualizer) process(d Display, cfg DisplayConfig, pix []byte) {
-	now := time.Now()
-	if len(cfg.Segments) == 0 {
-		return
-	}
-
-	src := &image.RGBA{
-		Pix:    pix,
-		Stride: d.Width() * 4,
-		Rect:   image.Rect(0, 0, d.Width(), d.Height()),
-	}
-
-	segs := make([]visualizer.Segment, len(cfg.Segments))
-
-	var wg sync.WaitGroup
-	wg.Add(len(cfg.Segments))
-
-	for i, seg := range cfg.Segments {
-		go func(i int, seg Segment) {
-			defer wg.Done()
-
-			rect := image.Rect(seg.From.X, seg.From.Y, seg.To.X, seg.To.Y)
-
-			sub := src.SubImage(rect)
-
-			var dst *image.RGBA
-
-			if rect.Dx() > rect.Dy() {
-				// horizontal
-				dst = image.NewRGBA(image.Rect(0, 0, seg.Leds, 1))
-			} else {
-				// vertical
-				dst = image.NewRGBA(image.Rect(0, 0, 1, seg.Leds))
-			}
-
-			v.scalers[seg.Id].Scale(dst, dst.Bounds(), sub, sub.Bounds(), draw.Over, nil)
-
-			colors := []color.Color{}
-
-			for i := 0; i < len(dst.Pix); i += 4 {
-				clr, _ := colorful.MakeColor(color.NRGBA{
-					R: dst.Pix[i],
-					G: dst.Pix[i+1],
-					B: dst.Pix[i+2],
-					A: dst.Pix[i+3],
-				})
-
-				colors = append(colors, clr)
-			}
-
-			if seg.Reverse {
-				reverse(colors)
-			}
-
-			segs[i] = visualizer.Segment{
-				Id:  seg.Id,
-				Pix: colors,
-			}
-		}(i, seg)
-
-	}
-
-	wg.Wait()
-
-	v.events <- visualizer.UpdateEvent{
-		Segments: segs,
-		Latency:  time.Since(now),
-	}
-}
-
-func reverse[S ~[]E, E any](s S) {
-	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
-		s[i], s[j] = s[j], s[i]
-	}
-}
-
 func (v *Visualizer) Stop() error {
 	if v.cancel != nil {
 		v.cancel()
@@ -292,8 +305,12 @@ func (v *Visualizer) matchDisplays(displays []Display) (map[int]DisplayConfig, e
 					continue
 				}
 
-				widthEq := sysd.Width() == displayCfg.Width
-				heightEq := sysd.Height() == displayCfg.Height
+				// configs describe the physical display, so a rotated
+				// desktop has its width and height swapped
+				width, height := sysd.Orientation().physical(sysd.Width(), sysd.Height())
+
+				widthEq := width == displayCfg.Width
+				heightEq := height == displayCfg.Height
 				leftEq := sysd.X() == displayCfg.Left
 				topEq := sysd.Y() == displayCfg.Top
 
@@ -343,6 +360,24 @@ func New(opts ...Option) (*Visualizer, error) {
 		return nil, fmt.Errorf("invalid display repository")
 	}
 
+	for _, cfgs := range v.displayConfigs {
+		for _, cfg := range cfgs {
+			err := cfg.Processing.Validate()
+			if err != nil {
+				return nil, fmt.Errorf("invalid processing for display %d: %w", cfg.Id, err)
+			}
+
+			err = cfg.Smoothing.Validate()
+			if err != nil {
+				return nil, fmt.Errorf("invalid smoothing for display %d: %w", cfg.Id, err)
+			}
+		}
+	}
+
+	if v.workers == 0 {
+		v.workers = runtime.NumCPU()
+	}
+
 	v.events = make(chan visualizer.UpdateEvent)
 
 	return v, nil
@@ -0,0 +1,176 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"ledctl3/internal/client/visualizer"
+)
+
+// fakeDisplay replays a synthetic frame as fast as it is consumed.
+type fakeDisplay struct {
+	id, width, height int
+	orientation       Orientation
+	frames            int
+	pix               []byte
+}
+
+func (d *fakeDisplay) Id() int                  { return d.id }
+func (d *fakeDisplay) Width() int               { return d.width }
+func (d *fakeDisplay) Height() int              { return d.height }
+func (d *fakeDisplay) X() int                   { return 0 }
+func (d *fakeDisplay) Y() int                   { return 0 }
+func (d *fakeDisplay) Resolution() string       { return fmt.Sprintf("%dx%d", d.width, d.height) }
+func (d *fakeDisplay) String() string           { return d.Resolution() }
+func (d *fakeDisplay) Close() error             { return nil }
+func (d *fakeDisplay) Orientation() Orientation { return d.orientation }
+
+func (d *fakeDisplay) Capture(ctx context.Context, _ int) chan []byte {
+	frames := make(chan []byte)
+
+	go func() {
+		defer close(frames)
+
+		for i := 0; i < d.frames; i++ {
+			select {
+			case frames <- d.pix:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return frames
+}
+
+type fakeRepository []Display
+
+func (r fakeRepository) All() ([]Display, error) {
+	return r, nil
+}
+
+// newFrame creates an RGBA frame with a pattern of fine detail.
+func newFrame(width, height int) []byte {
+	pix := make([]byte, width*height*4)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 4
+			pix[i] = uint8(x)
+			pix[i+1] = uint8(y)
+			pix[i+2] = uint8(x ^ y)
+			pix[i+3] = 0xff
+		}
+	}
+
+	return pix
+}
+
+// newAmbilight creates a visualizer for a display framed by four segments.
+func newAmbilight(tb testing.TB, d *fakeDisplay, leds int) *Visualizer {
+	w, h := d.width, d.height
+	depth := h / 10
+
+	v, err := New(
+		WithDisplayRepository(fakeRepository{d}),
+		WithDisplayConfig([][]DisplayConfig{{{
+			Id:        0,
+			Width:     w,
+			Height:    h,
+			Framerate: 60,
+			Segments: []Segment{
+				{Id: 0, Leds: leds, From: Vector2{0, 0}, To: Vector2{w, depth}},
+				{Id: 1, Leds: leds / 2, From: Vector2{w - depth, 0}, To: Vector2{w, h}},
+				{Id: 2, Leds: leds, From: Vector2{0, h - depth}, To: Vector2{w, h}, Reverse: true},
+				{Id: 3, Leds: leds / 2, From: Vector2{0, 0}, To: Vector2{depth, h}, Reverse: true},
+			},
+		}}}),
+	)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return v
+}
+
+// capture runs the visualizer over all the frames of the display and returns
+// the amount of events it emitted.
+func capture(tb testing.TB, v *Visualizer) int {
+	done := make(chan int)
+
+	go func() {
+		n := 0
+		for evt := range v.Events() {
+			n++
+			evt.Release()
+		}
+		done <- n
+	}()
+
+	err := v.startCapture(context.Background())
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	close(v.events)
+
+	return <-done
+}
+
+func TestCapture(t *testing.T) {
+	d := &fakeDisplay{width: 64, height: 36, frames: 3, pix: make([]byte, 64*36*4)}
+
+	// a red top edge
+	for i := 0; i < 64*4*4; i += 4 {
+		d.pix[i] = 0xff
+		d.pix[i+3] = 0xff
+	}
+
+	v := newAmbilight(t, d, 8)
+
+	events := make(chan visualizer.UpdateEvent, 3)
+	go func() {
+		for evt := range v.Events() {
+			seg := evt.Segments[0]
+			if len(seg.Pix) != 8*6 || seg.Pix[0] != 0xff || seg.Pix[2] != 0 {
+				t.Errorf("unexpected pixels %v", seg.Pix)
+			}
+
+			evt.Release()
+			events <- evt
+		}
+		close(events)
+	}()
+
+	err := v.startCapture(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(v.events)
+
+	n := 0
+	for range events {
+		n++
+	}
+
+	if n != 3 {
+		t.Fatalf("expected 3 events, got %d", n)
+	}
+}
+
+func BenchmarkCapture4K(b *testing.B) {
+	d := &fakeDisplay{width: 3840, height: 2160, pix: newFrame(3840, 2160)}
+	v := newAmbilight(b, d, 120)
+
+	d.frames = b.N
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	n := capture(b, v)
+	if n != b.N {
+		b.Fatalf("expected %d events, got %d", b.N, n)
+	}
+}
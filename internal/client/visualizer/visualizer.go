@@ -17,12 +17,29 @@ type Visualizer interface {
 type UpdateEvent struct {
 	Segments []Segment
 	Latency  time.Duration
+	// Release, if set, hands the pixel buffers of the segments back to the
+	// visualizer for reuse. They must not be used after calling it.
+	Release func()
 }
 
 // Segment is an LED strip segment.
 type Segment struct {
 	// ID is the unique identifier of the Segment.
 	Id int
-	// Pix contains color data for each LED. Must be a multiple of 4 (RGBA).
-	Pix []color.Color
+	// Pix contains the color of each LED as 16-bit big-endian RGB.
+	Pix []uint8
+}
+
+// AppendColors appends colors to pix as 16-bit big-endian RGB.
+func AppendColors(pix []uint8, colors ...color.Color) []uint8 {
+	for _, c := range colors {
+		r, g, b, _ := c.RGBA()
+		pix = append(pix,
+			uint8(r>>8), uint8(r),
+			uint8(g>>8), uint8(g),
+			uint8(b>>8), uint8(b),
+		)
+	}
+
+	return pix
 }
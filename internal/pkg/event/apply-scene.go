@@ -0,0 +1,11 @@
+package event
+
+type ApplySceneEvent struct {
+	Event      Type     `json:"event"`
+	Name       string   `json:"name"`
+	Transition *float64 `json:"transition,omitempty"`
+}
+
+func (e ApplySceneEvent) Type() Type {
+	return ApplyScene
+}
@@ -12,6 +12,9 @@ type ConnectedEvent struct {
 type ConnectedEventSegment struct {
 	Id   int `json:"id"`
 	Leds int `json:"leds"`
+	// Width and Height are the dimensions of matrix segments.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
 }
 
 func (e ConnectedEvent) Type() Type {
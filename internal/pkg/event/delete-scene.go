@@ -0,0 +1,10 @@
+package event
+
+type DeleteSceneEvent struct {
+	Event Type   `json:"event"`
+	Name  string `json:"name"`
+}
+
+func (e DeleteSceneEvent) Type() Type {
+	return DeleteScene
+}
@@ -9,14 +9,25 @@ import (
 type Type string
 
 const (
-	Connected   Type = "connected"
-	Update      Type = "update"
-	SetLeds     Type = "setLeds"
-	SetColor    Type = "setColor"
-	SetEffect   Type = "setEffect"
-	SetGradient Type = "setGradient"
-	TurnOn      Type = "turnOn"
-	TurnOff     Type = "turnOff"
+	Connected     Type = "connected"
+	Update        Type = "update"
+	SetLeds       Type = "setLeds"
+	SetFrame      Type = "setFrame"
+	SetText       Type = "setText"
+	SetImage      Type = "setImage"
+	SetColor      Type = "setColor"
+	SetEffect     Type = "setEffect"
+	SetGradient   Type = "setGradient"
+	SetBrightness Type = "setBrightness"
+	TurnOn        Type = "turnOn"
+	TurnOff       Type = "turnOff"
+	SaveScene     Type = "saveScene"
+	ApplyScene    Type = "applyScene"
+	DeleteScene   Type = "deleteScene"
+	ListScenes    Type = "listScenes"
+	Scenes        Type = "scenes"
+	Ping          Type = "ping"
+	Pong          Type = "pong"
 )
 
 type Event interface {
@@ -132,6 +143,22 @@ func FromJSON(typ Type, b []byte) (Event, error) {
 		var e SetLedsEvent
 		err := json.Unmarshal(b, &e)
 		return e, err
+	case SetFrame:
+		var e SetFrameEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case SetText:
+		var e SetTextEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case SetImage:
+		var e SetImageEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case SetBrightness:
+		var e SetBrightnessEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
 	case TurnOff:
 		var e TurnOffEvent
 		err := json.Unmarshal(b, &e)
@@ -140,10 +167,38 @@ func FromJSON(typ Type, b []byte) (Event, error) {
 		var e TurnOnEvent
 		err := json.Unmarshal(b, &e)
 		return e, err
+	case SaveScene:
+		var e SaveSceneEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case ApplyScene:
+		var e ApplySceneEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case DeleteScene:
+		var e DeleteSceneEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case ListScenes:
+		var e ListScenesEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case Scenes:
+		var e ScenesEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
 	case Update:
 		var e UpdateEvent
 		err := json.Unmarshal(b, &e)
 		return e, err
+	case Ping:
+		var e PingEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
+	case Pong:
+		var e PongEvent
+		err := json.Unmarshal(b, &e)
+		return e, err
 	default:
 		return nil, errors.New("invalid type")
 	}
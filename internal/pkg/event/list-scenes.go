@@ -0,0 +1,11 @@
+package event
+
+// ListScenesEvent requests the stored scenes, which the server replies to with
+// a ScenesEvent.
+type ListScenesEvent struct {
+	Event Type `json:"event"`
+}
+
+func (e ListScenesEvent) Type() Type {
+	return ListScenes
+}
@@ -0,0 +1,13 @@
+package event
+
+// PingEvent is sent by the client to estimate the clock offset and round-trip
+// time between itself and the server. Origin is the client's transmit time in
+// unix nanoseconds.
+type PingEvent struct {
+	Event  Type  `json:"event"`
+	Origin int64 `json:"origin"`
+}
+
+func (e PingEvent) Type() Type {
+	return Ping
+}
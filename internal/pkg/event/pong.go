@@ -0,0 +1,15 @@
+package event
+
+// PongEvent is the server's reply to a PingEvent. Origin is copied from the
+// ping, Receive and Transmit are the server's receive and transmit times, all
+// in unix nanoseconds.
+type PongEvent struct {
+	Event    Type  `json:"event"`
+	Origin   int64 `json:"origin"`
+	Receive  int64 `json:"receive"`
+	Transmit int64 `json:"transmit"`
+}
+
+func (e PongEvent) Type() Type {
+	return Pong
+}
@@ -0,0 +1,13 @@
+package event
+
+// SaveSceneEvent saves the state of segments as a scene, replacing any scene of
+// the same name. Without segment ids, the scene contains all segments.
+type SaveSceneEvent struct {
+	Event    Type   `json:"event"`
+	Name     string `json:"name"`
+	Segments []int  `json:"segments,omitempty"`
+}
+
+func (e SaveSceneEvent) Type() Type {
+	return SaveScene
+}
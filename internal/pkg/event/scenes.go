@@ -0,0 +1,16 @@
+package event
+
+// ScenesEvent is the server's reply to a ListScenesEvent.
+type ScenesEvent struct {
+	Event  Type               `json:"event"`
+	Scenes []ScenesEventScene `json:"scenes"`
+}
+
+type ScenesEventScene struct {
+	Name     string `json:"name"`
+	Segments []int  `json:"segments"`
+}
+
+func (e ScenesEvent) Type() Type {
+	return Scenes
+}
@@ -0,0 +1,13 @@
+package event
+
+// SetBrightnessEvent changes the brightness of a segment, from 0 to 255.
+type SetBrightnessEvent struct {
+	Event      Type     `json:"event"`
+	SegmentId  int      `json:"segmentId"`
+	Brightness int      `json:"brightness"`
+	Transition *float64 `json:"transition,omitempty"`
+}
+
+func (e SetBrightnessEvent) Type() Type {
+	return SetBrightness
+}
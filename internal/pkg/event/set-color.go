@@ -1,9 +1,10 @@
 package event
 
 type SetColorEvent struct {
-	Event     Type   `json:"event"`
-	SegmentId int    `json:"segmentId"`
-	Color     string `json:"color"`
+	Event      Type     `json:"event"`
+	SegmentId  int      `json:"segmentId"`
+	Color      string   `json:"color"`
+	Transition *float64 `json:"transition,omitempty"`
 }
 
 func (e SetColorEvent) Type() Type {
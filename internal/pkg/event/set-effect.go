@@ -5,12 +5,17 @@ type Effect string
 const (
 	Rainbow   Effect = "rainbow"
 	Christmas Effect = "christmas"
+	WakeUp    Effect = "wakeUp"
 )
 
+// SetEffectEvent starts an effect on a segment. Duration is how long effects
+// that end take in seconds, e.g. the wake-up light.
 type SetEffectEvent struct {
-	Event     Type   `json:"event"`
-	SegmentId int    `json:"segmentId"`
-	Effect    Effect `json:"effect"`
+	Event      Type     `json:"event"`
+	SegmentId  int      `json:"segmentId"`
+	Effect     Effect   `json:"effect"`
+	Duration   float64  `json:"duration,omitempty"`
+	Transition *float64 `json:"transition,omitempty"`
 }
 
 func (e SetEffectEvent) Type() Type {
@@ -0,0 +1,23 @@
+package event
+
+import "fmt"
+
+type SetFrameEvent struct {
+	Event     Type `json:"event"`
+	SegmentId int  `json:"segmentId"`
+	// Width and Height are the dimensions of the image in Pix. It is scaled
+	// to the size of the matrix if they don't match.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Pix holds the 8-bit RGBA pixels of the image in row-major order,
+	// starting at the top left.
+	Pix []byte `json:"pix"`
+}
+
+func (e SetFrameEvent) Type() Type {
+	return SetFrame
+}
+
+func (e SetFrameEvent) String() string {
+	return fmt.Sprint(e.SegmentId, e.Width, e.Height)
+}
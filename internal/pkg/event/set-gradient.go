@@ -1,14 +1,23 @@
 package event
 
+// SetGradientEvent shows a gradient on a segment. Space is the color space it
+// is interpolated in: linear, luv, hcl or oklab. Cyclic gradients wrap around
+// from the last step to the first, and scroll by Speed lengths of the segment
+// per second.
 type SetGradientEvent struct {
-	Event     Type                   `json:"event"`
-	SegmentId int                    `json:"segmentId"`
-	Steps     []SetGradientEventStep `json:"steps"`
+	Event      Type                   `json:"event"`
+	SegmentId  int                    `json:"segmentId"`
+	Steps      []SetGradientEventStep `json:"steps"`
+	Space      string                 `json:"space,omitempty"`
+	Cyclic     bool                   `json:"cyclic,omitempty"`
+	Speed      float64                `json:"speed,omitempty"`
+	Transition *float64               `json:"transition,omitempty"`
 }
 
 type SetGradientEventStep struct {
 	Color    string  `json:"color"`
 	Position float64 `json:"position"`
+	Easing   string  `json:"easing,omitempty"`
 }
 
 func (e SetGradientEvent) Type() Type {
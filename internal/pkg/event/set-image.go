@@ -0,0 +1,20 @@
+package event
+
+import "fmt"
+
+type SetImageEvent struct {
+	Event     Type `json:"event"`
+	SegmentId int  `json:"segmentId"`
+	// Image holds PNG or GIF data. Animated GIFs play with their own frame
+	// timing and loop count.
+	Image      []byte   `json:"image"`
+	Transition *float64 `json:"transition,omitempty"`
+}
+
+func (e SetImageEvent) Type() Type {
+	return SetImage
+}
+
+func (e SetImageEvent) String() string {
+	return fmt.Sprint(e.SegmentId, len(e.Image))
+}
@@ -6,6 +6,13 @@ type SetLedsEvent struct {
 	Event     Type   `json:"event"`
 	SegmentId int    `json:"segmentId"`
 	Pix       []byte `json:"pix"`
+	// Bits is the depth of each color component in Pix, either 8 or 16.
+	// 16-bit components are big-endian. Zero means 8 bits.
+	Bits int `json:"bits,omitempty"`
+	// Timestamp is the presentation time of the frame in the server's clock,
+	// in unix nanoseconds. A zero timestamp means the frame is rendered as
+	// soon as it arrives.
+	Timestamp int64 `json:"timestamp,omitempty"`
 }
 
 func (e SetLedsEvent) Type() Type {
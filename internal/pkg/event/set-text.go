@@ -0,0 +1,28 @@
+package event
+
+type Direction string
+
+const (
+	Left  Direction = "left"
+	Right Direction = "right"
+	Up    Direction = "up"
+	Down  Direction = "down"
+)
+
+type SetTextEvent struct {
+	Event     Type   `json:"event"`
+	SegmentId int    `json:"segmentId"`
+	Text      string `json:"text"`
+	Color     string `json:"color"`
+	// Speed is the scrolling speed in pixels per second. Zero shows the text
+	// without scrolling.
+	Speed     float64   `json:"speed,omitempty"`
+	Direction Direction `json:"direction,omitempty"`
+	// Loops is the number of times the text scrolls by. Zero means forever.
+	Loops      int      `json:"loops,omitempty"`
+	Transition *float64 `json:"transition,omitempty"`
+}
+
+func (e SetTextEvent) Type() Type {
+	return SetText
+}
@@ -1,8 +1,9 @@
 package event
 
 type TurnOffEvent struct {
-	Event     Type `json:"event"`
-	SegmentId int  `json:"segmentId"`
+	Event      Type     `json:"event"`
+	SegmentId  int      `json:"segmentId"`
+	Transition *float64 `json:"transition,omitempty"`
 }
 
 func (e TurnOffEvent) Type() Type {
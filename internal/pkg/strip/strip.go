@@ -46,3 +46,8 @@ func Parse(typ string) (Type, error) {
 
 	return t, nil
 }
+
+// HasWhite reports whether the strip has a dedicated white channel.
+func (t Type) HasWhite() bool {
+	return len(t) == 4
+}
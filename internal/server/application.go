@@ -1,15 +1,18 @@
 package application
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"ledctl3/internal/pkg/event"
 	"ledctl3/internal/server/config"
-	"ledctl3/pkg/color"
-	"ledctl3/pkg/ws281x"
+	"ledctl3/pkg/jitterbuf"
+	"ledctl3/pkg/lut"
+	"ledctl3/pkg/schedule"
 
 	"github.com/gorilla/websocket"
 )
@@ -21,6 +24,21 @@ const (
 	Render Mode = "render"
 )
 
+const (
+	defaultJitterBuffer = 8
+	defaultFramerate    = 100
+
+	// maxLateness is how late a timestamped frame can be played out before
+	// it is discarded instead.
+	maxLateness = 20 * time.Millisecond
+
+	// maxLead is how far in the future a frame can be scheduled. Frames
+	// beyond it are assumed to come from an unsynchronized clock and are
+	// dropped, as playing them at once could reorder them with frames
+	// that are on time.
+	maxLead = 5 * time.Second
+)
+
 //
 //const (
 //	Ambilight Mode = "video"
@@ -41,24 +59,74 @@ const (
 type Application struct {
 	mux       sync.Mutex
 	events    chan []byte
-	ws        *ws281x.Engine
+	ws        Driver
+	driver    DriverType
 	mode      Mode
 	rendering bool
-	buffer    []byte
+	pending   bool
+	framerate int
+
+	// transitionTime is the default duration of transitions between colors
+	// and effects.
+	transitionTime time.Duration
+
+	// buffer holds the 16-bit input color of each LED as RGBW. It is
+	// corrected into output and written to the strip on every render.
+	pixMux   sync.Mutex
+	buffer   []uint16
+	output   []uint16
+	dither   ditherer
+	power    *Power
+	profiles []ledProfile
+	states   map[int]*segmentState
 
 	leds        int
-	stripType   string
-	gpioPin     int
-	brightness  int
+	channels    []Channel
 	segments    map[int]Segment
+	groups      map[int][]int
 	calibration map[int]Calibration
+
+	jitterBuffer int
+	playout      *jitterbuf.Buffer[[]event.SetLedsEvent]
+
+	// scenes holds the saved scenes. They are also stored on disk once they
+	// have been loaded from it.
+	sceneMux      sync.Mutex
+	scenes        []config.Scene
+	persistScenes bool
+
+	// clock is the time the scheduled jobs run by.
+	clock schedule.Clock
+	jobs  []schedule.Job
 }
 
+// Channel is a strip connected to one of the PWM channels. The LEDs of each
+// channel follow the LEDs of the previous channel in the buffer.
+type Channel struct {
+	gpioPin    int
+	stripType  string
+	brightness int
+	start      int
+	leds       int
+}
+
+// Segment is a part of the strip that is addressed by its id. Its colors are
+// kept in pix in the segment's own order, and indices holds the LED each one is
+// shown on.
 type Segment struct {
-	id    int
-	start int
-	end   int
-	leds  int
+	id         int
+	channel    int
+	leds       int
+	width      int
+	height     int
+	indices    []int
+	pix        []uint16
+	mirror     *int
+	correction Correction
+	lut        *lut.Table
+	white      white
+	dither     bool
+	idle       *idle
 }
 
 type Calibration struct {
@@ -68,6 +136,7 @@ type Calibration struct {
 	Green float64
 	Blue  float64
 	White float64
+	Lut   *lut.Table
 }
 
 var upgrader = websocket.Upgrader{
@@ -88,6 +157,7 @@ func New(c config.Config) (*Application, error) {
 	a := &Application{
 		events: make(chan []byte, 1),
 		ws:     nil,
+		clock:  schedule.SystemClock,
 	}
 
 	err = a.applyConfig(c)
@@ -95,7 +165,9 @@ func New(c config.Config) (*Application, error) {
 		return nil, err
 	}
 
-	err = a.reload(a.gpioPin, a.leds, a.brightness, a.stripType)
+	a.playout = jitterbuf.New[[]event.SetLedsEvent](a.jitterBuffer, maxLateness)
+
+	err = a.reload()
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -103,6 +175,11 @@ func New(c config.Config) (*Application, error) {
 	return a, nil
 }
 
+// Driver returns the driver the strip is rendered to.
+func (a *Application) Driver() Driver {
+	return a.ws
+}
+
 //func (ctl *Application) Handle(e events.EventType) {
 //	switch e.EventType {
 //	case events.Ambilight:
@@ -138,6 +215,10 @@ func New(c config.Config) (*Application, error) {
 //}
 
 func (a *Application) Start() error {
+	go a.playout.Run(context.Background(), a.playFrame)
+	go a.renderLoop(context.Background())
+	go a.runSchedule(context.Background())
+
 	http.HandleFunc(
 		"/ws", func(w http.ResponseWriter, req *http.Request) {
 			wsconn, err := upgrader.Upgrade(w, req, nil)
@@ -150,13 +231,20 @@ func (a *Application) Start() error {
 
 			wsconn.EnableWriteCompression(true)
 
+			// segments the client streamed to, which fall back once it
+			// disconnects
+			streamed := map[int]bool{}
+
 			for {
 				typ, b, err := wsconn.ReadMessage()
 				if err != nil {
 					fmt.Println("error during read", err)
+					a.HandleDisconnected(streamed)
 					return
 				}
 
+				recv := time.Now()
+
 				if typ != websocket.TextMessage {
 					fmt.Println("invalid message type")
 					continue
@@ -168,17 +256,35 @@ func (a *Application) Start() error {
 					continue
 				}
 
+				for _, e := range events {
+					switch e := e.(type) {
+					case event.PingEvent:
+						a.HandlePingEvent(wsconn, e, recv)
+					case event.ListScenesEvent:
+						a.HandleListScenesEvent(wsconn)
+					case event.SetLedsEvent:
+						streamed[e.SegmentId] = true
+					case event.SetFrameEvent:
+						streamed[e.SegmentId] = true
+					}
+				}
+
 				a.ProcessEvents(events...)
 			}
 		},
 	)
 
+	http.HandleFunc("/state", a.HandleState)
+	http.HandleFunc("/metrics", a.HandleMetrics)
+	http.HandleFunc("/scenes", a.HandleScenes)
+	http.HandleFunc("/scenes/", a.HandleScenes)
+
 	go http.ListenAndServe(":4197", nil)
 
 	return nil
 }
 
-func (a *Application) reload(gpioPin, ledsCount, brightness int, stripType string) error {
+func (a *Application) reload() error {
 	if a.ws != nil {
 		err := a.ws.Clear()
 		if err != nil {
@@ -188,13 +294,33 @@ func (a *Application) reload(gpioPin, ledsCount, brightness int, stripType strin
 		a.ws.Fini()
 	}
 
-	engine, err := ws281x.Init(gpioPin, ledsCount, brightness, stripType)
+	// Brightness is applied by the lookup tables in linear space, so the
+	// strips themselves always run at full brightness.
+	engine, err := newDriver(a.driver, a.channels)
 	if err != nil {
 		return err
 	}
 
 	a.ws = engine
 
+	a.pixMux.Lock()
+
+	ledsCount := 0
+	for _, ch := range a.channels {
+		ledsCount += ch.leds
+	}
+
+	a.leds = ledsCount
+
+	if len(a.buffer) != ledsCount*4 {
+		a.buffer = make([]uint16, ledsCount*4)
+		a.output = make([]uint16, ledsCount*4)
+		a.dither.resize(ledsCount * 4)
+	}
+
+	a.updateProfiles()
+	a.pixMux.Unlock()
+
 	//i := 0
 	//for _, s := range segments {
 	//	a.segments = append(
@@ -212,98 +338,242 @@ func (a *Application) reload(gpioPin, ledsCount, brightness int, stripType strin
 }
 
 func (a *Application) HandleUpdateEvent(e event.UpdateEvent) {
+	if len(a.channels) > 1 {
+		fmt.Println("update event: not supported with multiple channels")
+		return
+	}
+
 	leds := 0
 	for _, seg := range e.Segments {
 		leds += seg.Leds
 	}
 
-	err := a.reload(e.GpioPin, leds, e.Brightness, e.StripType)
+	a.pixMux.Lock()
+	a.channels[0] = Channel{
+		gpioPin:    e.GpioPin,
+		stripType:  e.StripType,
+		brightness: e.Brightness,
+		leds:       leds,
+	}
+	a.pixMux.Unlock()
+
+	err := a.reload()
 	if err != nil {
 		fmt.Println(err)
 	}
 }
 
 func (a *Application) HandleSetLedsEvent(e event.SetLedsEvent) {
-	seg, ok := a.segments[e.SegmentId]
-	if !ok {
-		fmt.Println("Segment doesn't exist:", e.SegmentId)
+	segs, err := a.resolve(e.SegmentId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	depth := 1
+	if e.Bits == 16 {
+		depth = 2
+	} else if e.Bits != 0 && e.Bits != 8 {
+		fmt.Println("Invalid pixel depth for segment:", e.SegmentId)
+		return
+	}
+
+	for _, seg := range segs {
+		a.setLeds(seg, e.Pix, depth)
+	}
+}
+
+// setLeds sets the colors of a segment from pixel data with the given number
+// of bytes per component.
+func (a *Application) setLeds(seg Segment, pix []byte, depth int) {
+	// Clients can send either RGB or RGBA data. For RGBW strips the white
+	// channel is derived from the RGB components unless the segment passes
+	// the fourth component through.
+	channels := 4
+	if len(pix) == seg.leds*3*depth {
+		channels = 3
+	} else if len(pix) != seg.leds*4*depth {
+		fmt.Println("Invalid pixel data length for segment:", seg.id)
 		return
 	}
 
+	component := func(offset int) uint16 {
+		if depth == 2 {
+			return uint16(pix[offset])<<8 | uint16(pix[offset+1])
+		}
+
+		return uint16(pix[offset]) * 0x101
+	}
+
+	a.pixMux.Lock()
+
 	for i := 0; i < seg.leds; i++ {
 		// Parse color data for current LED
-		offset := i * 4
+		offset := i * channels * depth
 
-		r := e.Pix[offset]
-		g := e.Pix[offset+1]
-		b := e.Pix[offset+2]
-		aa := e.Pix[offset+3]
+		seg.pix[i*4] = component(offset)
+		seg.pix[i*4+1] = component(offset + depth)
+		seg.pix[i*4+2] = component(offset + 2*depth)
 
-		// Set the current LED's color
-		// Not need to check for error
-		err := a.setLedColor(i+seg.start, r, g, b, aa)
-		if err != nil {
-			fmt.Println(err)
+		if channels == 4 {
+			seg.pix[i*4+3] = component(offset + 3*depth)
+		} else {
+			seg.pix[i*4+3] = 0
 		}
-
-		//out += color.RGB(seg.Pix[i], seg.Pix[i+1], seg.Pix[i+2], true).Sprintf(" ")
 	}
+
+	a.pixMux.Unlock()
+
+	a.stream(seg.id, time.Now())
 }
 
 func (a *Application) HandleSetColorEvent(e event.SetColorEvent) {
-	seg, ok := a.segments[e.SegmentId]
-	if !ok {
-		fmt.Println("Segment doesn't exist:", e.SegmentId)
-		return
+	a.setLook(e.SegmentId, config.Look{Color: e.Color}, e.Transition)
+}
+
+func (a *Application) HandleSetGradientEvent(e event.SetGradientEvent) {
+	steps := make([]config.GradientStep, len(e.Steps))
+	for i, step := range e.Steps {
+		steps[i] = config.GradientStep{Color: step.Color, Position: step.Position, Easing: step.Easing}
+	}
+
+	look := config.Look{
+		Gradient:      steps,
+		GradientSpace: e.Space,
+		Cyclic:        e.Cyclic,
+		Speed:         e.Speed,
 	}
 
-	clr, err := color.FromString(e.Color)
+	a.setLook(e.SegmentId, look, e.Transition)
+}
+
+func (a *Application) HandleSetEffectEvent(e event.SetEffectEvent) {
+	a.setLook(e.SegmentId, config.Look{Effect: string(e.Effect), Duration: e.Duration}, e.Transition)
+}
+
+func (a *Application) HandleTurnOffEvent(e event.TurnOffEvent) {
+	segs, err := a.resolve(e.SegmentId)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	r, g, b, aa := clr.RGBA()
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
 
-	for i := seg.start; i < seg.end; i++ {
-		err := a.setLedColor(i, uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(aa>>8))
-		if err != nil {
-			fmt.Println(err)
-			return
+	d := a.transitionDuration(e.Transition)
+
+	for _, seg := range segs {
+		st := a.states[seg.id]
+		if st.off != nil {
+			continue
 		}
+
+		// turning off cancels the wake-up light, so turning on again shows
+		// its current colors instead of starting the sunrise over
+		if _, ok := st.anim.(*wakeAnimation); ok {
+			st.anim = nil
+			st.look = nil
+		}
+
+		off := a.snapshot(seg, st)
+
+		a.transition(seg, st, make([]uint16, seg.leds*4), nil, d, time.Now())
+		st.off = off
+		st.look = off.look
 	}
 }
 
-func (a *Application) HandleTurnOffEvent(e event.TurnOffEvent) {
-	seg, ok := a.segments[e.SegmentId]
-	if !ok {
-		fmt.Println("Segment doesn't exist:", e.SegmentId)
+// HandleTurnOnEvent restores what a segment showed before it was turned off.
+func (a *Application) HandleTurnOnEvent(e event.TurnOnEvent) {
+	segs, err := a.resolve(e.SegmentId)
+	if err != nil {
+		fmt.Println(err)
 		return
 	}
 
-	for i := seg.start; i < seg.end; i++ {
-		err := a.setLedColor(i, 0, 0, 0, 0)
-		if err != nil {
-			fmt.Println(err)
-			return
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	d := a.transitionDuration(e.Transition)
+
+	for _, seg := range segs {
+		st := a.states[seg.id]
+		if st.off == nil {
+			continue
+		}
+
+		off := st.off
+
+		a.transition(seg, st, off.pix, off.anim, d, time.Now())
+		st.look = off.look
+	}
+}
+
+// setSegment changes a segment to either static colors or an animation, with a
+// transition of the given duration in seconds. look describes the new content,
+// if known.
+func (a *Application) setSegment(seg Segment, pix []uint16, anim animation, look *config.Look, transition *float64) {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	st := a.states[seg.id]
+
+	a.transition(seg, st, pix, anim, a.transitionDuration(transition), time.Now())
+	st.look = look
+}
+
+// resolve returns the segments an event addresses by id: either a single
+// segment, or the segments of a group. Mirror segments can't be addressed.
+func (a *Application) resolve(id int) ([]Segment, error) {
+	if seg, ok := a.segments[id]; ok {
+		if seg.mirror != nil {
+			return nil, fmt.Errorf("segment %d is a mirror", id)
 		}
+
+		return []Segment{seg}, nil
 	}
+
+	ids, ok := a.groups[id]
+	if !ok {
+		return nil, fmt.Errorf("segment doesn't exist: %d", id)
+	}
+
+	segs := make([]Segment, len(ids))
+	for i, id := range ids {
+		segs[i] = a.segments[id]
+	}
+
+	return segs, nil
 }
 
 func (a *Application) HandleConnected(wsconn *websocket.Conn) {
-	segs := make([]event.ConnectedEventSegment, len(a.segments))
-	for i, seg := range a.segments {
-		segs[i] = event.ConnectedEventSegment{
+	segs := make([]event.ConnectedEventSegment, 0, len(a.segments))
+	for _, seg := range a.segments {
+		if seg.mirror != nil {
+			continue
+		}
+
+		s := event.ConnectedEventSegment{
 			Id:   seg.id,
 			Leds: seg.leds,
 		}
+
+		if seg.height > 1 {
+			s.Width, s.Height = seg.width, seg.height
+		}
+
+		segs = append(segs, s)
 	}
 
+	// the event only describes a single strip
+	ch := a.channels[0]
+
 	e := event.ConnectedEvent{
 		Event:      event.Connected,
-		Brightness: a.brightness,
-		GpioPin:    a.gpioPin,
-		StripType:  a.stripType,
+		Brightness: ch.brightness,
+		GpioPin:    ch.gpioPin,
+		StripType:  ch.stripType,
 		Segments:   segs,
 	}
 
@@ -318,19 +588,169 @@ func (a *Application) HandleConnected(wsconn *websocket.Conn) {
 	}
 }
 
-func (a *Application) setLedColor(id int, r, g, b, aa uint8) error {
-	calib, ok := a.calibration[id]
-	if ok {
-		r = uint8(float64(r) * calib.Red)
-		g = uint8(float64(g) * calib.Green)
-		b = uint8(float64(b) * calib.Blue)
-		aa = uint8(float64(aa) * calib.White)
+// HandlePingEvent replies to a clock synchronization request. recv is the time
+// the message containing the ping was read from the connection.
+func (a *Application) HandlePingEvent(wsconn *websocket.Conn, e event.PingEvent, recv time.Time) {
+	pong := event.PongEvent{
+		Event:    event.Pong,
+		Origin:   e.Origin,
+		Receive:  recv.UnixNano(),
+		Transmit: time.Now().UnixNano(),
 	}
 
-	return a.ws.SetLedColor(id, r, g, b, aa)
+	b, err := json.Marshal(pong)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = wsconn.WriteMessage(websocket.TextMessage, b)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// schedule queues timestamped frames in the jitter buffer. Frames that share a
+// timestamp are played out together so that all segments update at once.
+func (a *Application) schedule(events []event.SetLedsEvent) {
+	frames := map[int64][]event.SetLedsEvent{}
+
+	for _, e := range events {
+		frames[e.Timestamp] = append(frames[e.Timestamp], e)
+	}
+
+	for ts, frame := range frames {
+		at := time.Unix(0, ts)
+
+		if lead := time.Until(at); lead > maxLead {
+			fmt.Println("dropping frame scheduled", lead.Round(time.Millisecond), "ahead, is the client clock synced?")
+			continue
+		}
+
+		a.playout.Push(at, frame)
+	}
+}
+
+func (a *Application) playFrame(frame []event.SetLedsEvent) {
+	for _, e := range frame {
+		a.HandleSetLedsEvent(e)
+	}
+
+	a.render()
+}
+
+// compose writes the colors of every segment to the LEDs it is shown on. Mirror
+// segments show the colors of their source segment, stretched to fit. pixMux
+// must be held.
+func (a *Application) compose() {
+	for _, seg := range a.segments {
+		src := seg
+		if seg.mirror != nil {
+			src = a.segments[*seg.mirror]
+		}
+
+		if src.leds == 0 {
+			continue
+		}
+
+		for i, led := range seg.indices {
+			if led >= a.leds {
+				// the strip was reloaded with fewer LEDs
+				continue
+			}
+
+			j := i
+			if src.leds != seg.leds {
+				j = i * src.leds / seg.leds
+			}
+
+			copy(a.buffer[led*4:led*4+4], src.pix[j*4:j*4+4])
+		}
+	}
+}
+
+// flush applies the color correction to the input color of every LED, limits
+// the power draw and writes the result to the strip, dithered down to 8 bits.
+func (a *Application) flush() {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	a.compose()
+
+	for i := 0; i < a.leds; i++ {
+		offset := i * 4
+
+		a.setLedColor(
+			i, a.buffer[offset], a.buffer[offset+1], a.buffer[offset+2], a.buffer[offset+3],
+		)
+	}
+
+	a.power.limit(a.output)
+
+	a.dither.active = false
+
+	for i := 0; i < a.leds; i++ {
+		offset := i * 4
+		dither := a.profiles[i].dither
+
+		err := a.ws.SetLedColor(
+			i,
+			a.dither.quantize(offset, a.output[offset], dither),
+			a.dither.quantize(offset+1, a.output[offset+1], dither),
+			a.dither.quantize(offset+2, a.output[offset+2], dither),
+			a.dither.quantize(offset+3, a.output[offset+3], dither),
+		)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+}
+
+// setLedColor color-corrects the input color of an LED into its output color.
+func (a *Application) setLedColor(id int, r, g, b, aa uint16) {
+	p := a.profiles[id]
+
+	if p.lut != nil {
+		r, g, b = p.lut.Apply(r, g, b)
+	}
+
+	t := p.tables
+	r, g, b = t[0].Apply(r), t[1].Apply(g), t[2].Apply(b)
+
+	switch p.white.mode {
+	case WhiteOff:
+		aa = 0
+	case WhitePassthrough:
+		aa = t[3].Apply(aa)
+	default:
+		r, g, b, aa = p.white.extract(r, g, b)
+	}
+
+	if calib := p.calibration; calib != nil {
+		r = uint16(float64(r) * calib.Red)
+		g = uint16(float64(g) * calib.Green)
+		b = uint16(float64(b) * calib.Blue)
+		aa = uint16(float64(aa) * calib.White)
+	}
+
+	if st := p.state; st != nil && st.brightness < 1 {
+		r = uint16(float64(r) * st.brightness)
+		g = uint16(float64(g) * st.brightness)
+		b = uint16(float64(b) * st.brightness)
+		aa = uint16(float64(aa) * st.brightness)
+	}
+
+	offset := id * 4
+	a.output[offset] = r
+	a.output[offset+1] = g
+	a.output[offset+2] = b
+	a.output[offset+3] = aa
 }
 
 func (a *Application) ProcessEvents(events ...event.Event) {
+	var scheduled []event.SetLedsEvent
+
 	for _, e := range events {
 		//fmt.Printf("<- %s\n", e)
 
@@ -338,26 +758,89 @@ func (a *Application) ProcessEvents(events ...event.Event) {
 		case event.SetColorEvent:
 			a.HandleSetColorEvent(e)
 		case event.SetEffectEvent:
-			fmt.Println("setEffect event: no handler")
+			a.HandleSetEffectEvent(e)
+		case event.SetGradientEvent:
+			a.HandleSetGradientEvent(e)
+		case event.SetFrameEvent:
+			a.HandleSetFrameEvent(e)
+		case event.SetTextEvent:
+			a.HandleSetTextEvent(e)
+		case event.SetImageEvent:
+			a.HandleSetImageEvent(e)
 		case event.SetLedsEvent:
+			if e.Timestamp != 0 {
+				scheduled = append(scheduled, e)
+				continue
+			}
+
 			a.HandleSetLedsEvent(e)
+		case event.SetBrightnessEvent:
+			a.HandleSetBrightnessEvent(e)
 		case event.TurnOffEvent:
 			a.HandleTurnOffEvent(e)
 		case event.TurnOnEvent:
-			fmt.Println("turnOn event: no handler")
+			a.HandleTurnOnEvent(e)
 		case event.UpdateEvent:
 			a.HandleUpdateEvent(e)
+		case event.SaveSceneEvent:
+			a.HandleSaveSceneEvent(e)
+		case event.ApplySceneEvent:
+			a.HandleApplySceneEvent(e)
+		case event.DeleteSceneEvent:
+			a.HandleDeleteSceneEvent(e)
+		case event.PingEvent, event.ListScenesEvent:
+			// replied to by the connection that received it
 		default:
 			fmt.Println("unknown event", e)
 		}
 	}
 
+	if len(scheduled) > 0 {
+		a.schedule(scheduled)
+	}
+
+	a.render()
+}
+
+// renderLoop keeps rendering at the configured framerate for as long as the
+// output changes between frames without new input, e.g. while dithering,
+// animating an effect or recovering from the power limit. It also falls back
+// on segments whose stream stopped.
+func (a *Application) renderLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second / time.Duration(a.framerate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := a.animate(time.Now())
+
+		a.pixMux.Lock()
+		// limited brightness recovers over the following frames
+		active := a.dither.active || a.power.limiting()
+		a.pixMux.Unlock()
+
+		if changed || active {
+			a.render()
+		}
+	}
+}
+
+// render pushes the current LED colors to the strip. If a render is already in
+// progress, another one is started as soon as it completes, so that the latest
+// colors are never left unrendered.
+func (a *Application) render() {
 	if a.ws == nil {
 		return
 	}
 
 	a.mux.Lock()
 	if a.rendering {
+		a.pending = true
 		a.mux.Unlock()
 
 		return
@@ -367,15 +850,24 @@ func (a *Application) ProcessEvents(events ...event.Event) {
 	a.mux.Unlock()
 
 	go func() {
-		defer func() {
+		for {
+			a.flush()
+
+			err := a.ws.Render()
+			if err != nil {
+				fmt.Println(err)
+			}
+
 			a.mux.Lock()
-			a.rendering = false
-			a.mux.Unlock()
-		}()
+			if !a.pending {
+				a.rendering = false
+				a.mux.Unlock()
 
-		err := a.ws.Render()
-		if err != nil {
-			fmt.Println(err)
+				return
+			}
+
+			a.pending = false
+			a.mux.Unlock()
 		}
 	}()
 }
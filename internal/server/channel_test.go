@@ -0,0 +1,72 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/ws281x"
+)
+
+func TestChannels(t *testing.T) {
+	a, err := New(config.Config{
+		Driver: "virtual",
+		Channels: []config.Channel{
+			{GpioPin: 18, StripType: "rgb", Brightness: 255, Leds: 3},
+			{GpioPin: 13, StripType: "rgbw", Brightness: 51},
+		},
+		Segments: []config.Segment{
+			{Id: 0, Channel: 1, Leds: 1, Curve: "linear"},
+			{Id: 1, Channel: 0, Leds: 2, Curve: "linear"},
+			{Id: 2, Channel: 1, Leds: 1, Curve: "linear"},
+		},
+	})
+	assert.Nil(t, err)
+
+	// segments are laid out within their channel, and the second channel
+	// starts after all LEDs of the first one
+	assert.Equal(t, 5, a.leds)
+	assert.Equal(t, []int{3}, a.segments[0].indices)
+	assert.Equal(t, []int{0, 1}, a.segments[1].indices)
+	assert.Equal(t, []int{4}, a.segments[2].indices)
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 1, Pix: []byte{255, 255, 255, 255, 255, 255}})
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 2, Pix: []byte{255, 255, 255}})
+
+	a.flush()
+	assert.Nil(t, a.Driver().Render())
+
+	frame := a.Driver().(*ws281x.Virtual).Frame()
+
+	// the RGB channel at full brightness
+	assert.Equal(t, uint32(0x00ffffff), frame[0])
+
+	// white is extracted on the RGBW channel, at its own brightness
+	assert.Equal(t, uint32(51)<<24, frame[4])
+}
+
+func TestChannelsInvalid(t *testing.T) {
+	_, err := New(config.Config{
+		Driver: "virtual",
+		Channels: []config.Channel{
+			{GpioPin: 18, StripType: "rgb", Brightness: 255, Leds: 1},
+		},
+		Segments: []config.Segment{
+			{Id: 0, Leds: 2},
+		},
+	})
+	assert.NotNil(t, err)
+
+	_, err = New(config.Config{
+		Driver: "virtual",
+		Channels: []config.Channel{
+			{GpioPin: 18, StripType: "rgb", Brightness: 255},
+		},
+		Segments: []config.Segment{
+			{Id: 0, Channel: 1, Leds: 2},
+		},
+	})
+	assert.NotNil(t, err)
+}
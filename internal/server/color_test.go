@@ -0,0 +1,19 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+)
+
+func TestSetColorForms(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "orange"})
+	assert.Equal(t, []uint16{0xffff, 0xa5a5, 0, 0}, a.segments[0].pix)
+
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "hsl(120, 100%, 50%)"})
+	assert.Equal(t, []uint16{0, 0xffff, 0, 0}, a.segments[0].pix)
+}
@@ -2,13 +2,37 @@ package application
 
 import (
 	"errors"
+	"time"
 
 	"ledctl3/internal/pkg/strip"
 	"ledctl3/internal/server/config"
+	"ledctl3/pkg/lut"
+	"ledctl3/pkg/ws281x"
 )
 
+// maxChannelLeds is the most LEDs a channel can drive, well beyond what a
+// single strip refreshes at a usable frame rate.
+const maxChannelLeds = 1 << 14
+
 func validateConfig(c config.Config) error {
-	_, err := strip.Parse(c.StripType)
+	chans := channelConfigs(c)
+
+	err := validateChannels(chans)
+	if err != nil {
+		return err
+	}
+
+	err = validateSegments(c.Segments, chans)
+	if err != nil {
+		return err
+	}
+
+	err = validateMirrors(c.Segments)
+	if err != nil {
+		return err
+	}
+
+	err = validateGroups(c.Groups, c.Segments)
 	if err != nil {
 		return err
 	}
@@ -18,6 +42,154 @@ func validateConfig(c config.Config) error {
 		return err
 	}
 
+	err = validatePower(c)
+	if err != nil {
+		return err
+	}
+
+	_, ok := drivers[c.Driver]
+	if !ok && c.Driver != "" {
+		return errors.New("invalid driver")
+	}
+
+	if c.Framerate < 0 || c.Framerate > 400 {
+		return errors.New("framerate must be between 0 and 400")
+	}
+
+	if c.Transition < 0 || c.Transition > maxTransition {
+		return errors.New("transition must be between 0 and 60 seconds")
+	}
+
+	if c.JitterBuffer < 0 || c.JitterBuffer > 256 {
+		return errors.New("jitter buffer size must be between 0 and 256 frames")
+	}
+
+	for _, t := range c.Schedule {
+		_, _, err = parseTask(t, c.Location)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// channelConfigs returns the configured channels, or a single channel that is
+// configured by the top-level strip settings if there are none.
+func channelConfigs(c config.Config) []config.Channel {
+	if len(c.Channels) > 0 {
+		return c.Channels
+	}
+
+	return []config.Channel{
+		{
+			GpioPin:    c.GpioPin,
+			StripType:  c.StripType,
+			Brightness: c.Brightness,
+		},
+	}
+}
+
+func validateChannels(chans []config.Channel) error {
+	if len(chans) > ws281x.MaxChannels {
+		return errors.New("at most two channels are supported")
+	}
+
+	pins := map[int]bool{}
+
+	for _, ch := range chans {
+		_, err := strip.Parse(ch.StripType)
+		if err != nil {
+			return err
+		}
+
+		if ch.Brightness < 0 || ch.Brightness > 255 {
+			return errors.New("brightness must be between 0 and 255")
+		}
+
+		if ch.Leds < 0 || ch.Leds > maxChannelLeds {
+			return errors.New("invalid channel LED count")
+		}
+
+		if pins[ch.GpioPin] {
+			return errors.New("duplicate channel GPIO pin")
+		}
+
+		pins[ch.GpioPin] = true
+	}
+
+	return nil
+}
+
+func validateSegments(segs []config.Segment, chans []config.Channel) error {
+	ids := map[int]bool{}
+
+	for _, seg := range segs {
+		if ids[seg.Id] {
+			return errors.New("duplicate segment id")
+		}
+
+		ids[seg.Id] = true
+
+		if seg.Leds < 0 || seg.Leds > maxChannelLeds {
+			return errors.New("invalid segment LED count")
+		}
+
+		if seg.Channel < 0 || seg.Channel >= len(chans) {
+			return errors.New("invalid segment channel")
+		}
+
+		// check each dimension before multiplying so the product can't
+		// overflow
+		if m := seg.Matrix; m != nil {
+			if m.Width <= 0 || m.Height <= 0 || m.Width > maxChannelLeds || m.Height > maxChannelLeds/m.Width {
+				return errors.New("invalid matrix size")
+			}
+		}
+
+		// ranges must lie within the channel, or within the most LEDs a
+		// channel can have if its length follows from its segments
+		limit := chans[seg.Channel].Leds
+		if limit == 0 {
+			limit = maxChannelLeds
+		}
+
+		for _, r := range seg.Ranges {
+			if r.Start < 0 || r.Start > r.End || r.End >= limit {
+				return errors.New("invalid segment range")
+			}
+		}
+	}
+
+	leds, _, err := mapSegments(segs, chans)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segs {
+		stripType := chans[seg.Channel].StripType
+
+		_, err := parseCorrection(seg)
+		if err != nil {
+			return err
+		}
+
+		_, err = lut.ParseInterpolation(seg.Interpolation)
+		if err != nil {
+			return err
+		}
+
+		_, err = parseWhite(seg, stripType)
+		if err != nil {
+			return err
+		}
+
+		_, err = parseIdle(seg, len(leds[seg.Id]))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -49,6 +221,11 @@ func validateCalibration(calib []config.Calibration) error {
 			return errors.New("calibration factor out of range")
 		}
 
+		_, err := lut.ParseInterpolation(c.Interpolation)
+		if err != nil {
+			return err
+		}
+
 		for i := c.Start; i <= c.End; i++ {
 			_, ok := calibs[i]
 			if ok {
@@ -63,25 +240,130 @@ func validateCalibration(calib []config.Calibration) error {
 }
 
 func (a *Application) applyConfig(c config.Config) (err error) {
-	offset := 0
+	chans := channelConfigs(c)
+
+	mapping, counts, err := mapSegments(c.Segments, chans)
+	if err != nil {
+		return err
+	}
+
+	channels := make([]Channel, len(chans))
+	leds := 0
+
+	for i, ch := range chans {
+		channels[i] = Channel{
+			gpioPin:    ch.GpioPin,
+			stripType:  ch.StripType,
+			brightness: ch.Brightness,
+			start:      leds,
+			leds:       counts[i],
+		}
+
+		leds += counts[i]
+	}
+
 	segs := map[int]Segment{}
+	states := map[int]*segmentState{}
+	luts := lutCache{}
 
 	for _, seg := range c.Segments {
+		corr, err := parseCorrection(seg)
+		if err != nil {
+			return err
+		}
+
+		t, err := luts.load(seg.Lut, seg.Interpolation)
+		if err != nil {
+			return err
+		}
+
+		w, err := parseWhite(seg, chans[seg.Channel].StripType)
+		if err != nil {
+			return err
+		}
+
+		// the segment's LEDs relative to the start of the strip
+		indices := mapping[seg.Id]
+		for i := range indices {
+			indices[i] += channels[seg.Channel].start
+		}
+
+		idle, err := parseIdle(seg, len(indices))
+		if err != nil {
+			return err
+		}
+
+		dither := true
+		if seg.Dither != nil {
+			dither = *seg.Dither
+		}
+
+		var pix []uint16
+		if seg.Mirror == nil {
+			pix = make([]uint16, len(indices)*4)
+		}
+
+		// segments that aren't a matrix are a single row
+		width, height := len(indices), 1
+		if seg.Matrix != nil {
+			width, height = seg.Matrix.Width, seg.Matrix.Height
+		}
+
 		segs[seg.Id] = Segment{
-			id:    seg.Id,
-			leds:  seg.Leds,
-			start: offset,
-			end:   offset + seg.Leds,
+			id:         seg.Id,
+			channel:    seg.Channel,
+			leds:       len(indices),
+			width:      width,
+			height:     height,
+			indices:    indices,
+			pix:        pix,
+			mirror:     seg.Mirror,
+			correction: corr,
+			lut:        t,
+			white:      w,
+			dither:     dither,
+			idle:       idle,
 		}
+		states[seg.Id] = &segmentState{brightness: 1}
+	}
 
-		offset += seg.Leds
+	groups := map[int][]int{}
+	for _, g := range c.Groups {
+		groups[g.Id] = g.Segments
 	}
 
-	a.leds = offset
-	a.gpioPin = c.GpioPin
-	a.stripType = c.StripType
-	a.brightness = c.Brightness
+	a.leds = leds
+	a.channels = channels
 	a.segments = segs
+	a.groups = groups
+	a.states = states
+	a.buffer = make([]uint16, a.leds*4)
+	a.output = make([]uint16, a.leds*4)
+	a.dither.resize(a.leds * 4)
+
+	a.driver = drivers[c.Driver]
+	if a.driver == "" {
+		a.driver = WS281xDriver
+	}
+
+	a.framerate = c.Framerate
+	if a.framerate == 0 {
+		a.framerate = defaultFramerate
+	}
+
+	a.transitionTime = time.Duration(c.Transition * float64(time.Second))
+
+	a.jitterBuffer = c.JitterBuffer
+	if a.jitterBuffer == 0 {
+		a.jitterBuffer = defaultJitterBuffer
+	}
+
+	jobs, err := a.parseSchedule(c)
+	if err != nil {
+		return err
+	}
+
+	a.jobs = jobs
 
 	a.calibration = map[int]Calibration{}
 
@@ -90,15 +372,64 @@ func (a *Application) applyConfig(c config.Config) (err error) {
 			return errors.New("calibration index out of range")
 		}
 
+		t, err := luts.load(c.Lut, c.Interpolation)
+		if err != nil {
+			return err
+		}
+
+		calib := Calibration{
+			Red:   c.Red,
+			Green: c.Green,
+			Blue:  c.Blue,
+			White: c.White,
+			Lut:   t,
+		}
+
+		if t != nil && calib.Red == 0 && calib.Green == 0 && calib.Blue == 0 && calib.White == 0 {
+			// a calibration entry that only specifies a LUT should not
+			// turn the LEDs off
+			calib.Red, calib.Green, calib.Blue, calib.White = 1, 1, 1, 1
+		}
+
 		for i := c.Start; i <= c.End; i++ {
-			a.calibration[i] = Calibration{
-				Red:   c.Red,
-				Green: c.Green,
-				Blue:  c.Blue,
-				White: c.White,
-			}
+			a.calibration[i] = calib
 		}
 	}
 
+	a.updateProfiles()
+	a.applyPowerConfig(c)
+
 	return nil
 }
+
+// lutCache makes sure LUT files that are shared between segments or
+// calibration ranges are only loaded and compiled once.
+type lutCache map[string]*lut.Table
+
+func (c lutCache) load(name, interpolation string) (*lut.Table, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	interp, err := lut.ParseInterpolation(interpolation)
+	if err != nil {
+		return nil, err
+	}
+
+	key := name + ":" + string(interp)
+
+	t, ok := c[key]
+	if ok {
+		return t, nil
+	}
+
+	l, err := lut.Load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t = l.Compile(interp)
+	c[key] = t
+
+	return t, nil
+}
@@ -6,27 +6,182 @@ import (
 )
 
 type Config struct {
-	StripType   string        `yaml:"stripType" json:"stripType"`
-	GpioPin     int           `yaml:"gpioPin" json:"gpioPin"`
-	Brightness  int           `yaml:"brightness" json:"brightness"`
-	Segments    []Segment     `yaml:"segments" json:"segments"`
-	Calibration []Calibration `yaml:"calibration" json:"calibration"`
+	StripType    string        `yaml:"stripType" json:"stripType"`
+	GpioPin      int           `yaml:"gpioPin" json:"gpioPin"`
+	Brightness   int           `yaml:"brightness" json:"brightness"`
+	Channels     []Channel     `yaml:"channels" json:"channels,omitempty"`
+	Segments     []Segment     `yaml:"segments" json:"segments"`
+	Groups       []Group       `yaml:"groups" json:"groups,omitempty"`
+	Calibration  []Calibration `yaml:"calibration" json:"calibration"`
+	JitterBuffer int           `yaml:"jitterBuffer" json:"jitterBuffer"`
+	Power        Power         `yaml:"power" json:"power"`
+	Driver       string        `yaml:"driver" json:"driver"`
+	Framerate    int           `yaml:"framerate" json:"framerate"`
+	Transition   float64       `yaml:"transition" json:"transition"`
+	Location     *Location     `yaml:"location" json:"location,omitempty"`
+	Schedule     []Task        `yaml:"schedule" json:"schedule,omitempty"`
 }
 
+// Channel is a strip connected to one of the PWM channels. If no channels are
+// configured, a single one is created from StripType, GpioPin and Brightness.
+// Leds defaults to the number of LEDs of the channel's segments.
+type Channel struct {
+	GpioPin    int    `yaml:"gpioPin" json:"gpioPin"`
+	StripType  string `yaml:"stripType" json:"stripType"`
+	Brightness int    `yaml:"brightness" json:"brightness"`
+	Leds       int    `yaml:"leds" json:"leds,omitempty"`
+}
+
+// Segment is a part of a channel that is addressed by its id. Skip lists LEDs
+// of the channel that are left out of the segment, e.g. dead LEDs or LEDs that
+// are hidden. A mirror segment shows the colors of the segment with the id in
+// Mirror instead of its own.
 type Segment struct {
-	Id   int `yaml:"id" json:"id"`
-	Leds int `yaml:"leds" json:"leds"`
+	Id               int     `yaml:"id" json:"id"`
+	Channel          int     `yaml:"channel" json:"channel,omitempty"`
+	Leds             int     `yaml:"leds" json:"leds"`
+	Ranges           []Range `yaml:"ranges" json:"ranges,omitempty"`
+	Skip             []int   `yaml:"skip" json:"skip,omitempty"`
+	Mirror           *int    `yaml:"mirror" json:"mirror,omitempty"`
+	Matrix           *Matrix `yaml:"matrix" json:"matrix,omitempty"`
+	Curve            string  `yaml:"curve" json:"curve,omitempty"`
+	Gamma            *Gamma  `yaml:"gamma" json:"gamma,omitempty"`
+	Lut              string  `yaml:"lut" json:"lut,omitempty"`
+	Interpolation    string  `yaml:"interpolation" json:"interpolation,omitempty"`
+	WhiteMode        string  `yaml:"whiteMode" json:"whiteMode,omitempty"`
+	WhiteTemperature float64 `yaml:"whiteTemperature" json:"whiteTemperature,omitempty"`
+	MaxCurrent       float64 `yaml:"maxCurrent" json:"maxCurrent,omitempty"`
+	Dither           *bool   `yaml:"dither" json:"dither,omitempty"`
+	Idle             *Idle   `yaml:"idle" json:"idle,omitempty"`
 }
 
-type Calibration struct {
-	Start int     `yaml:"start" json:"start"`
-	End   int     `yaml:"end" json:"end"`
+// Range is a range of LEDs of a channel, between Start and End inclusive. A
+// segment made of ranges addresses their LEDs in order, and the LEDs of a
+// reversed range from End to Start. Without ranges, a segment covers the next
+// Leds LEDs of its channel.
+type Range struct {
+	Start   int  `yaml:"start" json:"start"`
+	End     int  `yaml:"end" json:"end"`
+	Reverse bool `yaml:"reverse" json:"reverse,omitempty"`
+}
+
+// Matrix arranges the LEDs of a segment in a grid. Origin is the corner of the
+// first LED: topLeft, topRight, bottomLeft or bottomRight. Wiring is either
+// progressive, where every row starts on the same side, or serpentine, where
+// every other row runs backwards.
+type Matrix struct {
+	Width  int    `yaml:"width" json:"width"`
+	Height int    `yaml:"height" json:"height"`
+	Origin string `yaml:"origin" json:"origin,omitempty"`
+	Wiring string `yaml:"wiring" json:"wiring,omitempty"`
+}
+
+// Group lets events address several segments at once by the group's id.
+type Group struct {
+	Id       int   `yaml:"id" json:"id"`
+	Segments []int `yaml:"segments" json:"segments"`
+}
+
+// Idle describes what a segment shows once clients stop streaming to it. After
+// Timeout seconds without frames the segment fades to the fallback over Fade
+// seconds. Without a fallback color, gradient or effect it fades to off.
+type Idle struct {
+	Timeout  float64        `yaml:"timeout" json:"timeout"`
+	Fade     float64        `yaml:"fade" json:"fade"`
+	Color    string         `yaml:"color" json:"color,omitempty"`
+	Gradient []GradientStep `yaml:"gradient" json:"gradient,omitempty"`
+	Effect   string         `yaml:"effect" json:"effect,omitempty"`
+}
+
+// GradientStep is a color at a position of a gradient between 0 and 1. Easing
+// is linear, easeIn, easeOut, easeInOut or hold, and shapes the interpolation
+// towards the next step.
+type GradientStep struct {
+	Color    string  `yaml:"color" json:"color"`
+	Position float64 `yaml:"position" json:"position"`
+	Easing   string  `yaml:"easing" json:"easing,omitempty"`
+}
+
+// Gamma holds the gamma exponent of each channel. It can be given either as a
+// single number that applies to all channels, or as an object with a value
+// per channel.
+type Gamma struct {
 	Red   float64 `yaml:"red" json:"red"`
 	Green float64 `yaml:"green" json:"green"`
 	Blue  float64 `yaml:"blue" json:"blue"`
 	White float64 `yaml:"white" json:"white"`
 }
 
+func (g *Gamma) UnmarshalJSON(b []byte) error {
+	var exp float64
+	if err := json.Unmarshal(b, &exp); err == nil {
+		*g = Gamma{Red: exp, Green: exp, Blue: exp, White: exp}
+		return nil
+	}
+
+	type gamma Gamma
+
+	var gg gamma
+	if err := json.Unmarshal(b, &gg); err != nil {
+		return err
+	}
+
+	*g = Gamma(gg)
+
+	return nil
+}
+
+type Calibration struct {
+	Start         int     `yaml:"start" json:"start"`
+	End           int     `yaml:"end" json:"end"`
+	Red           float64 `yaml:"red" json:"red"`
+	Green         float64 `yaml:"green" json:"green"`
+	Blue          float64 `yaml:"blue" json:"blue"`
+	White         float64 `yaml:"white" json:"white"`
+	Lut           string  `yaml:"lut" json:"lut,omitempty"`
+	Interpolation string  `yaml:"interpolation" json:"interpolation,omitempty"`
+}
+
+// Power describes the power supply of the strip. Currents are in mA.
+type Power struct {
+	Voltage        float64     `yaml:"voltage" json:"voltage"`
+	ChannelCurrent float64     `yaml:"channelCurrent" json:"channelCurrent"`
+	IdleCurrent    float64     `yaml:"idleCurrent" json:"idleCurrent"`
+	MaxCurrent     float64     `yaml:"maxCurrent" json:"maxCurrent"`
+	Injections     []Injection `yaml:"injections" json:"injections"`
+}
+
+// Injection is a point where power is fed into the strip. It supplies the LEDs
+// between Start and End inclusive.
+type Injection struct {
+	Start      int     `yaml:"start" json:"start"`
+	End        int     `yaml:"end" json:"end"`
+	MaxCurrent float64 `yaml:"maxCurrent" json:"maxCurrent"`
+}
+
+// Location is where the strips are, which sunrise and sunset are computed for.
+type Location struct {
+	Latitude  float64 `yaml:"latitude" json:"latitude"`
+	Longitude float64 `yaml:"longitude" json:"longitude"`
+}
+
+// Task runs an action at the times of either a cron expression in local time,
+// or the Sun event "sunrise" or "sunset" shifted by Offset minutes. The action
+// is either applying a Scene, processing Event, which is a single event or an
+// array of events, or changing the Brightness of the segment or group with
+// SegmentId.
+type Task struct {
+	Cron   string  `yaml:"cron" json:"cron,omitempty"`
+	Sun    string  `yaml:"sun" json:"sun,omitempty"`
+	Offset float64 `yaml:"offset" json:"offset,omitempty"`
+
+	Scene      string          `yaml:"scene" json:"scene,omitempty"`
+	Event      json.RawMessage `yaml:"event" json:"event,omitempty"`
+	Brightness *int            `yaml:"brightness" json:"brightness,omitempty"`
+	SegmentId  int             `yaml:"segmentId" json:"segmentId,omitempty"`
+	Transition *float64        `yaml:"transition" json:"transition,omitempty"`
+}
+
 var name = "ledctl.json"
 
 func (c Config) Save() error {
@@ -67,13 +222,27 @@ func Load() (Config, error) {
 
 func createDefault() (Config, error) {
 	c := Config{
-		StripType:  "rgb",
-		GpioPin:    18,
-		Brightness: 255,
+		StripType:    "rgb",
+		GpioPin:      18,
+		Brightness:   255,
+		JitterBuffer: 8,
+		Driver:       "ws281x",
+		Framerate:    100,
+		Transition:   0.5,
+		Power: Power{
+			Voltage:        5,
+			ChannelCurrent: 20,
+			IdleCurrent:    1,
+		},
 		Segments: []Segment{
 			{
-				Id:   0,
-				Leds: 100,
+				Id:    0,
+				Leds:  100,
+				Curve: "cie",
+				Idle: &Idle{
+					Timeout: 10,
+					Fade:    2,
+				},
 			},
 		},
 	}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Scene is a named state of some segments that can be applied at once.
+type Scene struct {
+	Name     string         `yaml:"name" json:"name"`
+	Segments []SceneSegment `yaml:"segments" json:"segments"`
+}
+
+// SceneSegment is the state of a segment in a scene. Brightness ranges from 0
+// to 255. A segment without a look keeps what it shows when the scene is
+// applied.
+type SceneSegment struct {
+	Id         int   `yaml:"id" json:"id"`
+	On         bool  `yaml:"on" json:"on"`
+	Brightness int   `yaml:"brightness" json:"brightness"`
+	Look       *Look `yaml:"look" json:"look,omitempty"`
+}
+
+// Look is what a segment shows: either a color, a gradient, an effect, text or
+// an image. Gradients are interpolated in GradientSpace, which is linear, luv,
+// hcl or oklab, and cyclic gradients scroll by Speed lengths of the segment per
+// second. Duration is how long effects that end take in seconds.
+type Look struct {
+	Color         string         `yaml:"color" json:"color,omitempty"`
+	Gradient      []GradientStep `yaml:"gradient" json:"gradient,omitempty"`
+	GradientSpace string         `yaml:"gradientSpace" json:"gradientSpace,omitempty"`
+	Cyclic        bool           `yaml:"cyclic" json:"cyclic,omitempty"`
+	Speed         float64        `yaml:"speed" json:"speed,omitempty"`
+	Effect        string         `yaml:"effect" json:"effect,omitempty"`
+	Duration      float64        `yaml:"duration" json:"duration,omitempty"`
+	Text          *Text          `yaml:"text" json:"text,omitempty"`
+	Image         []byte         `yaml:"image" json:"image,omitempty"`
+}
+
+// Text is scrolling text on a matrix segment.
+type Text struct {
+	Text      string  `yaml:"text" json:"text"`
+	Color     string  `yaml:"color" json:"color"`
+	Speed     float64 `yaml:"speed" json:"speed"`
+	Direction string  `yaml:"direction" json:"direction,omitempty"`
+	Loops     int     `yaml:"loops" json:"loops,omitempty"`
+}
+
+// scenesName is the file scenes are stored in, next to the config file.
+var scenesName = filepath.Join(filepath.Dir(name), "scenes.json")
+
+func SaveScenes(scenes []Scene) error {
+	b, err := json.MarshalIndent(scenes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(scenesName, b, 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadScenes loads the stored scenes. There are none until a scene is saved.
+func LoadScenes() ([]Scene, error) {
+	b, err := os.ReadFile(scenesName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var scenes []Scene
+	err = json.Unmarshal(b, &scenes)
+	if err != nil {
+		return nil, err
+	}
+
+	return scenes, nil
+}
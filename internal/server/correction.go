@@ -0,0 +1,170 @@
+package application
+
+import (
+	"errors"
+
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/gamma"
+	"ledctl3/pkg/lut"
+)
+
+type Curve string
+
+const (
+	LinearCurve Curve = "linear"
+	GammaCurve  Curve = "gamma"
+	CIECurve    Curve = "cie"
+)
+
+var curves = map[string]Curve{
+	"linear": LinearCurve,
+	"gamma":  GammaCurve,
+	"cie":    CIECurve,
+}
+
+// defaultGamma is used for the channels that do not specify an exponent.
+const defaultGamma = 2.2
+
+// Correction describes how input levels of a segment are mapped to output
+// levels.
+type Correction struct {
+	Curve Curve
+	Red   float64
+	Green float64
+	Blue  float64
+	White float64
+}
+
+// tables holds the precomputed lookup tables for the red, green, blue and
+// white channels, with the global brightness already applied.
+type tables [4]*gamma.Table
+
+// ledProfile holds everything needed to correct the color of a single LED,
+// resolved from the segment and calibration range it belongs to.
+type ledProfile struct {
+	tables      *tables
+	lut         *lut.Table
+	white       white
+	calibration *Calibration
+	dither      bool
+
+	// state is the state of the LED's segment, which scales its brightness.
+	state *segmentState
+}
+
+func parseCorrection(seg config.Segment) (Correction, error) {
+	c := Correction{
+		Curve: LinearCurve,
+		Red:   defaultGamma,
+		Green: defaultGamma,
+		Blue:  defaultGamma,
+		White: defaultGamma,
+	}
+
+	if seg.Gamma != nil {
+		c.Curve = GammaCurve
+
+		exps := []*float64{&c.Red, &c.Green, &c.Blue, &c.White}
+		vals := []float64{seg.Gamma.Red, seg.Gamma.Green, seg.Gamma.Blue, seg.Gamma.White}
+
+		for i, v := range vals {
+			if v < 0 || v > 5 {
+				return Correction{}, errors.New("gamma exponent out of range")
+			}
+
+			if v != 0 {
+				*exps[i] = v
+			}
+		}
+	}
+
+	if seg.Curve != "" {
+		curve, ok := curves[seg.Curve]
+		if !ok {
+			return Correction{}, errors.New("invalid curve")
+		}
+
+		c.Curve = curve
+	}
+
+	return c, nil
+}
+
+func (c Correction) tables(brightness float64) *tables {
+	var t tables
+
+	switch c.Curve {
+	case GammaCurve:
+		t[0] = gamma.NewTable(gamma.Power(c.Red), brightness)
+		t[1] = gamma.NewTable(gamma.Power(c.Green), brightness)
+		t[2] = gamma.NewTable(gamma.Power(c.Blue), brightness)
+		t[3] = gamma.NewTable(gamma.Power(c.White), brightness)
+	case CIECurve:
+		t[0] = gamma.NewTable(gamma.CIE(), brightness)
+		t[1], t[2], t[3] = t[0], t[0], t[0]
+	default:
+		t[0] = gamma.NewTable(gamma.Linear(), brightness)
+		t[1], t[2], t[3] = t[0], t[0], t[0]
+	}
+
+	return &t
+}
+
+// updateProfiles resolves the profile of every LED and recomputes the lookup
+// tables that depend on the brightness.
+func (a *Application) updateProfiles() {
+	a.profiles = make([]ledProfile, a.leds)
+
+	for _, ch := range a.channels {
+		brightness := float64(ch.brightness) / 255
+
+		fallbackWhite, _ := parseWhite(config.Segment{}, ch.stripType)
+		fallback := ledProfile{
+			tables: Correction{Curve: LinearCurve}.tables(brightness),
+			white:  fallbackWhite,
+			dither: true,
+		}
+
+		for i := ch.start; i < ch.start+ch.leds && i < a.leds; i++ {
+			a.profiles[i] = fallback
+		}
+	}
+
+	for _, seg := range a.segments {
+		brightness := float64(a.channels[seg.channel].brightness) / 255
+		t := seg.correction.tables(brightness)
+
+		// mirrors follow the brightness of the segment they mirror
+		st := a.states[seg.id]
+		if seg.mirror != nil {
+			st = a.states[*seg.mirror]
+		}
+
+		for _, i := range seg.indices {
+			if i >= a.leds {
+				continue
+			}
+
+			a.profiles[i] = ledProfile{
+				tables: t,
+				lut:    seg.lut,
+				white:  seg.white,
+				dither: seg.dither,
+				state:  st,
+			}
+		}
+	}
+
+	for i, calib := range a.calibration {
+		if i >= a.leds {
+			continue
+		}
+
+		calib := calib
+		a.profiles[i].calibration = &calib
+
+		if calib.Lut != nil {
+			a.profiles[i].lut = calib.Lut
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package application
+
+// ditherThreshold is the smallest fraction of an 8-bit step, in 1/256ths, that
+// is dithered. Smaller fractions are treated as rounding errors of the color
+// correction and are rounded instead, so that 8-bit input does not keep the
+// render loop busy.
+const ditherThreshold = 4
+
+// ditherer reduces 16-bit output levels to the 8 bits supported by the strip.
+// With temporal dithering the rounding error of each channel is carried over
+// to the next frame, so that averaged over a few frames the output matches the
+// 16-bit level. This avoids visible banding in slow fades at low brightness.
+type ditherer struct {
+	// residue holds the accumulated error of each channel, in 1/256ths of
+	// an 8-bit step.
+	residue []uint8
+	// active is set if any dithered channel had a level between two 8-bit
+	// steps during the last frame, which means more frames need to be
+	// rendered for the output to average out.
+	active bool
+}
+
+func (d *ditherer) resize(channels int) {
+	if len(d.residue) != channels {
+		d.residue = make([]uint8, channels)
+	}
+}
+
+// quantize converts the 16-bit level of a channel to 8 bits.
+func (d *ditherer) quantize(ch int, v uint16, dither bool) uint8 {
+	// scale to 8.8 fixed point so that 0xffff maps to exactly 255.0
+	x := uint32(v) * 0xff00 / 0xffff
+
+	frac := x & 0xff
+
+	if !dither || frac < ditherThreshold || frac > 0x100-ditherThreshold {
+		d.residue[ch] = 0
+		return uint8((x + 0x80) >> 8)
+	}
+
+	d.active = true
+
+	acc := x + uint32(d.residue[ch])
+	d.residue[ch] = uint8(acc)
+
+	return uint8(acc >> 8)
+}
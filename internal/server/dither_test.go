@@ -0,0 +1,96 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/ws281x"
+)
+
+func newVirtual(t *testing.T, dither bool) (*Application, *ws281x.Virtual) {
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{Id: 0, Leds: 1, Curve: "linear", Dither: &dither},
+		},
+	})
+	assert.Nil(t, err)
+
+	v, ok := a.Driver().(*ws281x.Virtual)
+	assert.True(t, ok)
+
+	return a, v
+}
+
+// renderFrames renders n frames synchronously and returns the average level of
+// the red channel of the first LED.
+func renderFrames(t *testing.T, a *Application, v *ws281x.Virtual, n int) (avg float64, levels map[uint8]bool) {
+	levels = map[uint8]bool{}
+
+	var sum float64
+	for i := 0; i < n; i++ {
+		a.flush()
+		assert.Nil(t, a.Driver().Render())
+
+		r := uint8(v.Frame()[0] >> 16)
+		levels[r] = true
+		sum += float64(r)
+	}
+
+	return sum / float64(n), levels
+}
+
+func TestTemporalDithering(t *testing.T) {
+	a, v := newVirtual(t, true)
+
+	// a level between the 8-bit steps 18 and 19
+	level := uint16(0x1234)
+	a.HandleSetLedsEvent(event.SetLedsEvent{
+		SegmentId: 0,
+		Bits:      16,
+		Pix:       []byte{byte(level >> 8), byte(level), 0, 0, 0, 0},
+	})
+
+	avg, levels := renderFrames(t, a, v, 256)
+
+	assert.InDelta(t, float64(level)*255/0xffff, avg, 1.0/128)
+	assert.Equal(t, map[uint8]bool{18: true, 19: true}, levels)
+	assert.True(t, a.dither.active)
+}
+
+func TestDitheringDisabled(t *testing.T) {
+	a, v := newVirtual(t, false)
+
+	level := uint16(0x1234)
+	a.HandleSetLedsEvent(event.SetLedsEvent{
+		SegmentId: 0,
+		Bits:      16,
+		Pix:       []byte{byte(level >> 8), byte(level), 0, 0, 0, 0},
+	})
+
+	avg, levels := renderFrames(t, a, v, 16)
+
+	assert.Equal(t, 18.0, avg)
+	assert.Len(t, levels, 1)
+	assert.False(t, a.dither.active)
+}
+
+func TestDitheringExactLevels(t *testing.T) {
+	a, v := newVirtual(t, true)
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{
+		SegmentId: 0,
+		Pix:       []byte{200, 0, 0},
+	})
+
+	avg, levels := renderFrames(t, a, v, 16)
+
+	assert.Equal(t, 200.0, avg)
+	assert.Len(t, levels, 1)
+	assert.False(t, a.dither.active)
+}
@@ -0,0 +1,52 @@
+package application
+
+import (
+	"ledctl3/pkg/ws281x"
+)
+
+// Driver outputs colors to a physical or virtual LED strip.
+type Driver interface {
+	SetLedColor(index int, r, g, b, w uint8) error
+	Render() error
+	Clear() error
+	Fini()
+}
+
+type DriverType string
+
+const (
+	// WS281xDriver drives a strip connected to the GPIO of a Raspberry Pi.
+	WS281xDriver DriverType = "ws281x"
+	// VirtualDriver keeps the rendered frames in memory.
+	VirtualDriver DriverType = "virtual"
+)
+
+var drivers = map[string]DriverType{
+	"ws281x":  WS281xDriver,
+	"virtual": VirtualDriver,
+}
+
+// newDriver creates a driver for the given channels. The strips run at full
+// brightness.
+func newDriver(typ DriverType, channels []Channel) (Driver, error) {
+	chans := make([]ws281x.Channel, len(channels))
+	ledsCount := 0
+
+	for i, ch := range channels {
+		chans[i] = ws281x.Channel{
+			GpioPin:    ch.gpioPin,
+			LedCount:   ch.leds,
+			Brightness: 255,
+			StripType:  ch.stripType,
+		}
+
+		ledsCount += ch.leds
+	}
+
+	switch typ {
+	case VirtualDriver:
+		return ws281x.NewVirtual(ledsCount), nil
+	default:
+		return ws281x.InitChannels(chans...)
+	}
+}
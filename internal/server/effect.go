@@ -0,0 +1,109 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+
+	"ledctl3/internal/pkg/event"
+)
+
+const (
+	// rainbowPeriod is the time it takes the rainbow to move once around the
+	// segment.
+	rainbowPeriod = 10 * time.Second
+
+	// christmasStep is the time it takes the christmas pattern to move by one
+	// LED.
+	christmasStep = 250 * time.Millisecond
+	christmasSize = 4
+)
+
+// effects render a frame at time t since the effect started into pix, which
+// holds the 16-bit RGBW colors of a segment in rows of the given width.
+var effects = map[event.Effect]func(t time.Duration, pix []uint16, width int){
+	event.Rainbow:   rainbow,
+	event.Christmas: christmas,
+}
+
+// animation renders animated content on a segment, e.g. an effect or
+// scrolling text.
+type animation interface {
+	// render renders the frame at time t since the animation started into
+	// the colors of the segment. It reports whether the animation has ended.
+	render(t time.Duration, seg Segment) bool
+}
+
+// effectAnimation animates an effect until it is replaced.
+type effectAnimation event.Effect
+
+func (e effectAnimation) render(t time.Duration, seg Segment) bool {
+	fn, ok := effects[event.Effect(e)]
+	if !ok || seg.width == 0 {
+		return true
+	}
+
+	fn(t, seg.pix, seg.width)
+
+	return false
+}
+
+// parseEffect returns the animation of an effect. duration is how long effects
+// that end take in seconds, or 0 for their default.
+func parseEffect(s string, duration float64) (animation, error) {
+	e := event.Effect(s)
+
+	if e == event.WakeUp {
+		return newWakeAnimation(duration)
+	}
+
+	_, ok := effects[e]
+	if !ok {
+		return nil, errors.New("invalid effect")
+	}
+
+	return effectAnimation(e), nil
+}
+
+// rainbow cycles through the hues along a segment, or diagonally across a
+// matrix.
+func rainbow(t time.Duration, pix []uint16, width int) {
+	height := len(pix) / 4 / width
+	shift := float64(t%rainbowPeriod) / float64(rainbowPeriod)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pos := float64(x) / float64(width)
+			if height > 1 {
+				pos = (pos + float64(y)/float64(height)) / 2
+			}
+
+			h := math.Mod(pos+shift, 1)
+			c := colorful.Hsv(h*360, 1, 1)
+
+			setColor(pix, y*width+x, c.R, c.G, c.B)
+		}
+	}
+}
+
+func christmas(t time.Duration, pix []uint16, _ int) {
+	shift := int(t / christmasStep)
+
+	for i := 0; i < len(pix)/4; i++ {
+		if (i+shift)/christmasSize%2 == 0 {
+			setColor(pix, i, 1, 0, 0)
+		} else {
+			setColor(pix, i, 0, 1, 0)
+		}
+	}
+}
+
+func setColor(pix []uint16, i int, r, g, b float64) {
+	offset := i * 4
+	pix[offset] = uint16(math.Round(r * 0xffff))
+	pix[offset+1] = uint16(math.Round(g * 0xffff))
+	pix[offset+2] = uint16(math.Round(b * 0xffff))
+	pix[offset+3] = 0
+}
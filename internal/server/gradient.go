@@ -0,0 +1,63 @@
+package application
+
+import (
+	"time"
+
+	"ledctl3/internal/server/config"
+	clr "ledctl3/pkg/color"
+	"ledctl3/pkg/gradient"
+)
+
+// gradientAnimation scrolls a cyclic gradient along a segment.
+type gradientAnimation struct {
+	gradient *gradient.Gradient
+	// speed is in lengths of the segment per second.
+	speed  float64
+	colors []clr.RGBW
+}
+
+func newGradientAnimation(g *gradient.Gradient, speed float64, leds int) *gradientAnimation {
+	return &gradientAnimation{
+		gradient: g,
+		speed:    speed,
+		colors:   make([]clr.RGBW, leds),
+	}
+}
+
+func (g *gradientAnimation) render(t time.Duration, seg Segment) bool {
+	fillGradient(seg.pix, g.gradient, g.colors, -g.speed*t.Seconds())
+	return false
+}
+
+// parseGradient creates the gradient of a look.
+func parseGradient(look config.Look) (*gradient.Gradient, error) {
+	stops := make([]gradient.Stop, len(look.Gradient))
+
+	for i, step := range look.Gradient {
+		c, err := clr.FromString(step.Color)
+		if err != nil {
+			return nil, err
+		}
+
+		stops[i] = gradient.Stop{
+			Color:    c,
+			Position: step.Position,
+			Easing:   gradient.Easing(step.Easing),
+		}
+	}
+
+	return gradient.New(stops,
+		gradient.WithSpace(gradient.Space(look.GradientSpace)),
+		gradient.WithCyclic(look.Cyclic),
+	)
+}
+
+// fillGradient samples a gradient evenly across the LEDs of pix, using colors as
+// a buffer with one color per LED.
+func fillGradient(pix []uint16, g *gradient.Gradient, colors []clr.RGBW, offset float64) {
+	g.Sample(colors, offset)
+
+	for i, c := range colors {
+		fillColor(pix, i, c)
+	}
+}
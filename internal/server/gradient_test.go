@@ -0,0 +1,42 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+func TestGradientScroll(t *testing.T) {
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments:   []config.Segment{{Id: 0, Leds: 4}},
+	})
+	assert.Nil(t, err)
+
+	steps := []event.SetGradientEventStep{
+		{Color: "#ff000000", Position: 0},
+		{Color: "#0000ff00", Position: 0.5},
+	}
+
+	// gradients that aren't cyclic can't scroll
+	a.HandleSetGradientEvent(event.SetGradientEvent{SegmentId: 0, Steps: steps, Speed: 1})
+	assert.Nil(t, a.states[0].anim)
+
+	a.HandleSetGradientEvent(event.SetGradientEvent{SegmentId: 0, Steps: steps, Space: "linear", Cyclic: true, Speed: 0.25})
+
+	st := a.states[0]
+	pix := a.segments[0].pix
+	assert.Equal(t, uint16(0xffff), pix[0])
+	assert.Equal(t, uint16(0xffff), pix[2*4+2])
+
+	// a quarter of the way after a second, so the red LED moved by one
+	a.animate(st.started.Add(time.Second))
+	assert.Equal(t, uint16(0xffff), pix[1*4])
+	assert.Equal(t, uint16(0xffff), pix[3*4+2])
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"errors"
+	"image/color"
+	"time"
+
+	"ledctl3/internal/server/config"
+	clr "ledctl3/pkg/color"
+)
+
+// idle is what a segment falls back to once its stream stops.
+type idle struct {
+	timeout time.Duration
+	fade    time.Duration
+
+	// target holds the static colors of the fallback. It is unused if the
+	// fallback is an effect.
+	target []uint16
+	anim   animation
+	look   *config.Look
+}
+
+func parseIdle(seg config.Segment, leds int) (*idle, error) {
+	c := seg.Idle
+	if c == nil {
+		return nil, nil
+	}
+
+	if c.Timeout <= 0 {
+		return nil, errors.New("idle timeout must be positive")
+	}
+
+	if c.Fade < 0 {
+		return nil, errors.New("idle fade duration must not be negative")
+	}
+
+	fallbacks := 0
+	for _, set := range []bool{c.Color != "", len(c.Gradient) > 0, c.Effect != ""} {
+		if set {
+			fallbacks++
+		}
+	}
+
+	if fallbacks > 1 {
+		return nil, errors.New("idle fallback must be either a color, a gradient or an effect")
+	}
+
+	look := &config.Look{Color: c.Color, Gradient: c.Gradient, Effect: c.Effect}
+
+	target, anim, err := parseLook(*look, leds, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &idle{
+		timeout: time.Duration(c.Timeout * float64(time.Second)),
+		fade:    time.Duration(c.Fade * float64(time.Second)),
+		target:  target,
+		anim:    anim,
+		look:    look,
+	}, nil
+}
+
+func fillColor(pix []uint16, i int, c color.Color) {
+	rgbw := clr.ToRGBW(c)
+
+	offset := i * 4
+	pix[offset] = rgbw.R
+	pix[offset+1] = rgbw.G
+	pix[offset+2] = rgbw.B
+	pix[offset+3] = rgbw.W
+}
+
+// stream marks a segment as showing frames from a client, which cancels any
+// fallback that is running on it.
+func (a *Application) stream(id int, now time.Time) {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	st, ok := a.states[id]
+	if !ok {
+		return
+	}
+
+	*st = segmentState{
+		streaming:  true,
+		updated:    now,
+		brightness: st.brightness,
+		dim:        st.dim,
+	}
+}
+
+// HandleDisconnected falls back immediately on the segments a client was
+// streaming to when its connection closed, instead of waiting for the idle
+// timeout.
+func (a *Application) HandleDisconnected(segments map[int]bool) {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	now := time.Now()
+
+	for id := range segments {
+		segs, err := a.resolve(id)
+		if err != nil {
+			continue
+		}
+
+		for _, seg := range segs {
+			if st := a.states[seg.id]; seg.idle != nil && st.streaming {
+				a.fallback(seg, st, now)
+			}
+		}
+	}
+}
+
+// fallback starts fading a segment to its idle fallback. pixMux must be held.
+func (a *Application) fallback(seg Segment, st *segmentState, now time.Time) {
+	a.transition(seg, st, seg.idle.target, seg.idle.anim, seg.idle.fade, now)
+	st.look = seg.idle.look
+}
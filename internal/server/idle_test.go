@@ -0,0 +1,95 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+func newIdle(t *testing.T, idle config.Idle) *Application {
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{Id: 0, Leds: 2, Curve: "linear", Idle: &idle},
+		},
+	})
+	assert.Nil(t, err)
+
+	return a
+}
+
+func TestIdleFade(t *testing.T) {
+	a := newIdle(t, config.Idle{Timeout: 1, Fade: 2, Color: "#0000ff00"})
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 0, Pix: []byte{255, 0, 0, 255, 0, 0}})
+	start := a.states[0].updated
+
+	assert.False(t, a.animate(start.Add(500*time.Millisecond)))
+	assert.Equal(t, uint16(0xffff), a.segments[0].pix[0])
+
+	// timeout elapsed, the fade starts
+	assert.True(t, a.animate(start.Add(time.Second)))
+	assert.Equal(t, uint16(0xffff), a.segments[0].pix[0])
+
+	// halfway through, the lightness is halfway between red and blue
+	assert.True(t, a.animate(start.Add(2*time.Second)))
+	l, _, _ := oklab(a.segments[0].pix[0], a.segments[0].pix[1], a.segments[0].pix[2])
+	red, _, _ := oklab(0xffff, 0, 0)
+	blue, _, _ := oklab(0, 0, 0xffff)
+	assert.InDelta(t, (red+blue)/2, l, 0.001)
+
+	assert.True(t, a.animate(start.Add(3*time.Second)))
+	assert.Equal(t, []uint16{0, 0, 0xffff, 0}, a.segments[0].pix[:4])
+
+	// the fade is done and nothing is animated anymore
+	assert.False(t, a.animate(start.Add(4*time.Second)))
+}
+
+func TestIdleResume(t *testing.T) {
+	a := newIdle(t, config.Idle{Timeout: 1, Fade: 2})
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 0, Pix: []byte{255, 0, 0, 255, 0, 0}})
+	start := a.states[0].updated
+
+	a.animate(start.Add(time.Second))
+	a.animate(start.Add(2 * time.Second))
+	assert.NotNil(t, a.states[0].fade)
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 0, Pix: []byte{0, 255, 0, 0, 255, 0}})
+	assert.Nil(t, a.states[0].fade)
+	assert.True(t, a.states[0].streaming)
+	assert.Equal(t, []uint16{0, 0xffff, 0, 0}, a.segments[0].pix[:4])
+}
+
+func TestIdleDisconnected(t *testing.T) {
+	a := newIdle(t, config.Idle{Timeout: 10, Fade: 1, Effect: "rainbow"})
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 0, Pix: []byte{0, 0, 0, 0, 0, 0}})
+	a.HandleDisconnected(map[int]bool{0: true})
+
+	st := a.states[0]
+	assert.False(t, st.streaming)
+	assert.Equal(t, effectAnimation(event.Rainbow), st.anim)
+
+	// the effect keeps animating once the fade is done
+	assert.True(t, a.animate(st.started.Add(2*time.Second)))
+	assert.Nil(t, st.fade)
+	assert.True(t, a.animate(st.started.Add(3*time.Second)))
+}
+
+func TestIdleExplicitColor(t *testing.T) {
+	a := newIdle(t, config.Idle{Timeout: 1})
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{SegmentId: 0, Pix: []byte{255, 0, 0, 255, 0, 0}})
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "#00ff0000"})
+
+	// colors that were set explicitly don't time out
+	assert.False(t, a.animate(time.Now().Add(time.Minute)))
+	assert.Equal(t, []uint16{0, 0xffff, 0, 0}, a.segments[0].pix[:4])
+}
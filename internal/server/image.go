@@ -0,0 +1,187 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	_ "image/png"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+// defaultFrameDelay is the delay of GIF frames that don't specify one, the same
+// as in web browsers.
+const defaultFrameDelay = 100 * time.Millisecond
+
+// maxImageScale is how many times more pixels than its segment an image may
+// have. Images are decoded in full before they are scaled down, so this bounds
+// the memory a single event can use.
+const maxImageScale = 16
+
+// maxGIFFrames is the most frames an animated GIF may have.
+const maxGIFFrames = 1000
+
+// gifAnimation plays the frames of an animated GIF on a segment.
+type gifAnimation struct {
+	// frames holds the colors of each frame, scaled to the segment.
+	frames [][]uint16
+	delays []time.Duration
+	// loops is the number of times the animation plays, or 0 to play
+	// forever.
+	loops int
+}
+
+func (g *gifAnimation) render(t time.Duration, seg Segment) bool {
+	var length time.Duration
+	for _, d := range g.delays {
+		length += d
+	}
+
+	done := false
+
+	if g.loops > 0 && t >= length*time.Duration(g.loops) {
+		// stay on the last frame
+		t = length - 1
+		done = true
+	}
+
+	t %= length
+
+	for i, d := range g.delays {
+		if t < d {
+			copy(seg.pix, g.frames[i])
+			break
+		}
+
+		t -= d
+	}
+
+	return done
+}
+
+// decodeImage decodes PNG or GIF data and scales it to the size of a segment.
+// It returns the colors of a still image, or the animation of an animated GIF.
+func decodeImage(b []byte, width, height int) ([]uint16, *gifAnimation, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// compare the dimensions one at a time so the product can't overflow
+	limit := width * height * maxImageScale
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > limit || cfg.Height > limit/cfg.Width {
+		return nil, nil, errors.New("image is too large for the segment")
+	}
+
+	if bytes.HasPrefix(b, []byte("GIF8")) {
+		g, err := gif.DecodeAll(bytes.NewReader(b))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(g.Image) > maxGIFFrames {
+			return nil, nil, errors.New("too many GIF frames")
+		}
+
+		anim := decodeGIF(g, width, height)
+		if len(anim.frames) == 1 {
+			return anim.frames[0], nil, nil
+		}
+
+		return nil, anim, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scaleImage(img, width, height), nil, nil
+}
+
+// decodeGIF composes the frames of a GIF according to their disposal methods.
+func decodeGIF(g *gif.GIF, width, height int) *gifAnimation {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	canvas := image.NewRGBA(bounds)
+	previous := image.NewRGBA(bounds)
+
+	anim := &gifAnimation{}
+
+	switch {
+	case g.LoopCount == 0:
+		anim.loops = 0
+	case g.LoopCount < 0:
+		anim.loops = 1
+	default:
+		anim.loops = g.LoopCount + 1
+	}
+
+	for i, frame := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		if disposal == gif.DisposalPrevious {
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		anim.frames = append(anim.frames, scaleImage(canvas, width, height))
+
+		delay := defaultFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		anim.delays = append(anim.delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous.Pix)
+		}
+	}
+
+	return anim
+}
+
+// scaleImage scales an image to the given size and returns its colors blended
+// with black.
+func scaleImage(img image.Image, width, height int) []uint16 {
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+
+	if img.Bounds().Dx() == width && img.Bounds().Dy() == height {
+		draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	} else {
+		draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	}
+
+	pix := make([]uint16, width*height*4)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// the colors are premultiplied by alpha, which blends them
+			// with black
+			c := dst.RGBA64At(x, y)
+
+			offset := (y*width + x) * 4
+			pix[offset] = c.R
+			pix[offset+1] = c.G
+			pix[offset+2] = c.B
+		}
+	}
+
+	return pix
+}
+
+func (a *Application) HandleSetImageEvent(e event.SetImageEvent) {
+	a.setLook(e.SegmentId, config.Look{Image: e.Image}, e.Transition)
+}
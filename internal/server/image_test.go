@@ -0,0 +1,93 @@
+package application
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+)
+
+func TestSetImageGIF(t *testing.T) {
+	a := newMatrix(t, 2, 2)
+
+	palette := color.Palette{color.Black, color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}}
+
+	red := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	green := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	for i := range red.Pix {
+		red.Pix[i] = 1
+		green.Pix[i] = 2
+	}
+
+	var b bytes.Buffer
+	err := gif.EncodeAll(&b, &gif.GIF{
+		Image:     []*image.Paletted{red, green},
+		Delay:     []int{10, 50},
+		LoopCount: -1,
+	})
+	assert.Nil(t, err)
+
+	a.HandleSetImageEvent(event.SetImageEvent{SegmentId: 0, Image: b.Bytes()})
+
+	st := a.states[0]
+	pix := a.segments[0].pix
+	assert.Equal(t, []uint16{0xffff, 0, 0, 0}, pix[:4])
+
+	// the frames are shown for their own delays and the animation plays
+	// once
+	assert.True(t, a.animate(st.started.Add(50*time.Millisecond)))
+	assert.Equal(t, []uint16{0xffff, 0, 0, 0}, pix[:4])
+
+	a.animate(st.started.Add(150 * time.Millisecond))
+	assert.Equal(t, []uint16{0, 0xffff, 0, 0}, pix[:4])
+	assert.NotNil(t, st.anim)
+
+	a.animate(st.started.Add(time.Second))
+	assert.Equal(t, []uint16{0, 0xffff, 0, 0}, pix[:4])
+	assert.Nil(t, st.anim)
+}
+
+func TestSetImageTooLarge(t *testing.T) {
+	a := newMatrix(t, 2, 2)
+
+	// only the header of a GIF with a 65535×65535 logical screen, which is
+	// rejected before any frames are decoded
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	_, _, err := decodeImage(header, 2, 2)
+	assert.EqualError(t, err, "image is too large for the segment")
+
+	a.HandleSetImageEvent(event.SetImageEvent{SegmentId: 0, Image: header})
+	assert.Equal(t, make([]uint16, 16), a.segments[0].pix)
+
+	// an image a few times the size of the segment is still scaled down
+	var b bytes.Buffer
+	err = gif.Encode(&b, image.NewPaletted(image.Rect(0, 0, 8, 8), color.Palette{color.Black}), nil)
+	assert.Nil(t, err)
+
+	_, _, err = decodeImage(b.Bytes(), 2, 2)
+	assert.Nil(t, err)
+}
+
+func TestSetImageTooManyFrames(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black, color.White})
+
+	g := &gif.GIF{}
+	for i := 0; i <= maxGIFFrames; i++ {
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 1)
+	}
+
+	var b bytes.Buffer
+	err := gif.EncodeAll(&b, g)
+	assert.Nil(t, err)
+
+	_, _, err = decodeImage(b.Bytes(), 2, 2)
+	assert.EqualError(t, err, "too many GIF frames")
+}
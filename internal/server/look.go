@@ -0,0 +1,144 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	clr "ledctl3/pkg/color"
+)
+
+// parseLook returns the static colors or the animation of a look on a segment of
+// the given size. A look without content is off.
+func parseLook(look config.Look, width, height int) ([]uint16, animation, error) {
+	set := 0
+	for _, ok := range []bool{look.Color != "", len(look.Gradient) > 0, look.Effect != "", look.Text != nil, look.Image != nil} {
+		if ok {
+			set++
+		}
+	}
+
+	if set > 1 {
+		return nil, nil, errors.New("look must be either a color, a gradient, an effect, text or an image")
+	}
+
+	pix := make([]uint16, width*height*4)
+
+	switch {
+	case look.Color != "":
+		c, err := clr.FromString(look.Color)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := 0; i < width*height; i++ {
+			fillColor(pix, i, c)
+		}
+	case len(look.Gradient) > 0:
+		g, err := parseGradient(look)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if look.Speed != 0 {
+			if !look.Cyclic {
+				return nil, nil, errors.New("only cyclic gradients can scroll")
+			}
+
+			return nil, newGradientAnimation(g, look.Speed, width*height), nil
+		}
+
+		fillGradient(pix, g, make([]clr.RGBW, width*height), 0)
+	case look.Effect != "":
+		anim, err := parseEffect(look.Effect, look.Duration)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, anim, nil
+	case look.Text != nil:
+		t := look.Text
+
+		if height < 2 {
+			return nil, nil, errors.New("text requires a matrix segment")
+		}
+
+		c, err := clr.FromString(t.Color)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		anim, err := newTextAnimation(t.Text, c, t.Speed, event.Direction(t.Direction), t.Loops)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, anim, nil
+	case look.Image != nil:
+		pix, anim, err := decodeImage(look.Image, width, height)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if anim != nil {
+			return nil, anim, nil
+		}
+
+		return pix, nil, nil
+	}
+
+	return pix, nil, nil
+}
+
+// setLook changes the segments an event addresses to a look, with a transition
+// of the given duration in seconds.
+func (a *Application) setLook(id int, look config.Look, transition *float64) {
+	segs, err := a.resolve(id)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, seg := range segs {
+		pix, anim, err := parseLook(look, seg.width, seg.height)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		a.setSegment(seg, pix, anim, &look, transition)
+	}
+}
+
+func (a *Application) HandleSetBrightnessEvent(e event.SetBrightnessEvent) {
+	if e.Brightness < 0 || e.Brightness > 255 {
+		fmt.Println("brightness must be between 0 and 255")
+		return
+	}
+
+	segs, err := a.resolve(e.SegmentId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	d := a.transitionDuration(e.Transition)
+
+	for _, seg := range segs {
+		st := a.states[seg.id]
+
+		// changing the brightness by hand cancels the wake-up light, which
+		// stays at its current colors
+		if _, ok := st.anim.(*wakeAnimation); ok {
+			st.anim = nil
+			st.look = nil
+		}
+
+		a.setBrightness(st, float64(e.Brightness)/255, d, time.Now())
+	}
+}
@@ -0,0 +1,171 @@
+package application
+
+import (
+	"errors"
+
+	"ledctl3/internal/server/config"
+)
+
+// mapSegments resolves the LEDs of every segment as indices relative to the
+// start of its channel, in the order the segment addresses them. The LEDs of
+// matrix segments are reordered to row-major order from the top left. It also
+// returns the number of LEDs of each channel.
+func mapSegments(segs []config.Segment, chans []config.Channel) (map[int][]int, []int, error) {
+	leds := map[int][]int{}
+	counts := make([]int, len(chans))
+
+	// the segment that uses each LED of each channel
+	used := make([]map[int]bool, len(chans))
+	for i := range used {
+		used[i] = map[int]bool{}
+	}
+
+	// sequential segments follow each other within their channel
+	offsets := make([]int, len(chans))
+
+	for _, seg := range segs {
+		ch := seg.Channel
+
+		n := seg.Leds
+		if seg.Matrix != nil && n == 0 {
+			n = seg.Matrix.Width * seg.Matrix.Height
+		}
+
+		ranges := seg.Ranges
+		if len(ranges) == 0 && n > 0 {
+			ranges = []config.Range{{Start: offsets[ch], End: offsets[ch] + n - 1}}
+			offsets[ch] += n
+		}
+
+		skip := map[int]bool{}
+		for _, i := range seg.Skip {
+			skip[i] = true
+		}
+
+		idx := []int{}
+
+		for _, r := range ranges {
+			if r.Start < 0 || r.Start > r.End {
+				return nil, nil, errors.New("invalid segment range")
+			}
+
+			for i := r.Start; i <= r.End; i++ {
+				led := i
+				if r.Reverse {
+					led = r.End - (i - r.Start)
+				}
+
+				if skip[led] {
+					continue
+				}
+
+				if used[ch][led] {
+					return nil, nil, errors.New("segments overlap")
+				}
+
+				used[ch][led] = true
+				idx = append(idx, led)
+			}
+
+			if r.End+1 > counts[ch] {
+				counts[ch] = r.End + 1
+			}
+		}
+
+		if len(seg.Ranges) > 0 && seg.Leds != 0 && seg.Leds != len(idx) {
+			return nil, nil, errors.New("segment LED count doesn't match its ranges")
+		}
+
+		if seg.Matrix != nil {
+			order, err := matrixOrder(*seg.Matrix)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if len(order) != len(idx) {
+				return nil, nil, errors.New("matrix size doesn't match the segment's LED count")
+			}
+
+			wired := idx
+			idx = make([]int, len(order))
+			for i, p := range order {
+				idx[i] = wired[p]
+			}
+		}
+
+		leds[seg.Id] = idx
+	}
+
+	for i, ch := range chans {
+		if ch.Leds == 0 {
+			continue
+		}
+
+		if counts[i] > ch.Leds {
+			return nil, nil, errors.New("segments exceed the channel LED count")
+		}
+
+		counts[i] = ch.Leds
+	}
+
+	return leds, counts, nil
+}
+
+func validateMirrors(segs []config.Segment) error {
+	byId := map[int]config.Segment{}
+	for _, seg := range segs {
+		byId[seg.Id] = seg
+	}
+
+	for _, seg := range segs {
+		if seg.Mirror == nil {
+			continue
+		}
+
+		src, ok := byId[*seg.Mirror]
+		if !ok {
+			return errors.New("mirrored segment doesn't exist")
+		}
+
+		if src.Mirror != nil {
+			return errors.New("segment can't mirror another mirror")
+		}
+	}
+
+	return nil
+}
+
+func validateGroups(groups []config.Group, segs []config.Segment) error {
+	ids := map[int]bool{}
+	mirrors := map[int]bool{}
+
+	for _, seg := range segs {
+		ids[seg.Id] = false
+		mirrors[seg.Id] = seg.Mirror != nil
+	}
+
+	for _, g := range groups {
+		if _, ok := ids[g.Id]; ok {
+			return errors.New("duplicate group id")
+		}
+
+		ids[g.Id] = true
+
+		if len(g.Segments) == 0 {
+			return errors.New("group must contain at least one segment")
+		}
+
+		for _, id := range g.Segments {
+			group, ok := ids[id]
+			if !ok || group {
+				return errors.New("group segment doesn't exist")
+			}
+
+			if mirrors[id] {
+				return errors.New("group can't contain a mirror segment")
+			}
+		}
+	}
+
+	return nil
+}
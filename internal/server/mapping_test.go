@@ -0,0 +1,110 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/ws281x"
+)
+
+func TestVirtualSegments(t *testing.T) {
+	mirror := 0
+
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{
+				Id: 0,
+				Ranges: []config.Range{
+					{Start: 0, End: 2},
+					{Start: 6, End: 8, Reverse: true},
+				},
+				Skip:  []int{1},
+				Curve: "linear",
+			},
+			{
+				Id:     1,
+				Ranges: []config.Range{{Start: 3, End: 4, Reverse: true}},
+				Mirror: &mirror,
+				Curve:  "linear",
+			},
+		},
+		Groups: []config.Group{
+			{Id: 10, Segments: []int{0}},
+		},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 9, a.leds)
+	assert.Equal(t, []int{0, 2, 8, 7, 6}, a.segments[0].indices)
+	assert.Equal(t, 5, a.segments[0].leds)
+
+	a.HandleSetLedsEvent(event.SetLedsEvent{
+		SegmentId: 10,
+		Pix:       []byte{1, 0, 0, 2, 0, 0, 3, 0, 0, 4, 0, 0, 5, 0, 0},
+	})
+
+	// mirrors can't be addressed directly
+	_, err = a.resolve(1)
+	assert.NotNil(t, err)
+
+	a.flush()
+	assert.Nil(t, a.Driver().Render())
+
+	var reds []uint8
+	for _, c := range a.Driver().(*ws281x.Virtual).Frame() {
+		reds = append(reds, uint8(c>>16))
+	}
+
+	// the mirror stretches the five LEDs of the first segment onto its two
+	// LEDs in reverse, and skipped or unused LEDs stay off
+	assert.Equal(t, []uint8{1, 0, 2, 3, 1, 0, 5, 4, 3}, reds)
+}
+
+func TestVirtualSegmentsOverlap(t *testing.T) {
+	_, err := New(config.Config{
+		StripType: "rgb",
+		Driver:    "virtual",
+		Segments: []config.Segment{
+			{Id: 0, Leds: 4},
+			{Id: 1, Ranges: []config.Range{{Start: 3, End: 5}}},
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestSegmentRangeBounds(t *testing.T) {
+	for _, c := range []config.Config{
+		{
+			StripType: "rgb",
+			Driver:    "virtual",
+			Segments: []config.Segment{
+				{Id: 0, Ranges: []config.Range{{Start: 0, End: 1 << 40}}},
+			},
+		},
+		{
+			Driver: "virtual",
+			Channels: []config.Channel{
+				{StripType: "rgb", Leds: 10},
+			},
+			Segments: []config.Segment{
+				{Id: 0, Ranges: []config.Range{{Start: 5, End: 10}}},
+			},
+		},
+		{
+			StripType: "rgb",
+			Driver:    "virtual",
+			Segments: []config.Segment{
+				{Id: 0, Leds: 1 << 40},
+			},
+		},
+	} {
+		_, err := New(c)
+		assert.NotNil(t, err)
+	}
+}
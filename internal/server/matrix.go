@@ -0,0 +1,127 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+type Origin string
+
+const (
+	TopLeft     Origin = "topLeft"
+	TopRight    Origin = "topRight"
+	BottomLeft  Origin = "bottomLeft"
+	BottomRight Origin = "bottomRight"
+)
+
+type Wiring string
+
+const (
+	Progressive Wiring = "progressive"
+	Serpentine  Wiring = "serpentine"
+)
+
+var origins = map[string]Origin{
+	"":            TopLeft,
+	"topLeft":     TopLeft,
+	"topRight":    TopRight,
+	"bottomLeft":  BottomLeft,
+	"bottomRight": BottomRight,
+}
+
+var wirings = map[string]Wiring{
+	"":            Progressive,
+	"progressive": Progressive,
+	"serpentine":  Serpentine,
+}
+
+// matrixOrder returns the position in the wiring order of each LED of a
+// matrix, with the LEDs in row-major order from the top left.
+func matrixOrder(m config.Matrix) ([]int, error) {
+	if m.Width <= 0 || m.Height <= 0 {
+		return nil, errors.New("invalid matrix size")
+	}
+
+	origin, ok := origins[m.Origin]
+	if !ok {
+		return nil, errors.New("invalid matrix origin")
+	}
+
+	wiring, ok := wirings[m.Wiring]
+	if !ok {
+		return nil, errors.New("invalid matrix wiring")
+	}
+
+	order := make([]int, m.Width*m.Height)
+
+	for p := range order {
+		row, col := p/m.Width, p%m.Width
+
+		if wiring == Serpentine && row%2 == 1 {
+			col = m.Width - 1 - col
+		}
+
+		x, y := col, row
+
+		if origin == TopRight || origin == BottomRight {
+			x = m.Width - 1 - col
+		}
+
+		if origin == BottomLeft || origin == BottomRight {
+			y = m.Height - 1 - row
+		}
+
+		order[y*m.Width+x] = p
+	}
+
+	return order, nil
+}
+
+func (a *Application) HandleSetFrameEvent(e event.SetFrameEvent) {
+	segs, err := a.resolve(e.SegmentId)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if e.Width <= 0 || e.Height <= 0 || len(e.Pix) != e.Width*e.Height*4 {
+		fmt.Println("Invalid frame size for segment:", e.SegmentId)
+		return
+	}
+
+	for _, seg := range segs {
+		a.setFrame(seg, e)
+	}
+}
+
+// setFrame scales an RGBA image to the size of a segment, using the nearest
+// pixel, and sets it as the segment's colors. Transparent pixels are blended
+// with black. A segment that isn't a matrix is a single row.
+func (a *Application) setFrame(seg Segment, e event.SetFrameEvent) {
+	a.pixMux.Lock()
+
+	for y := 0; y < seg.height; y++ {
+		sy := y * e.Height / seg.height
+
+		for x := 0; x < seg.width; x++ {
+			sx := x * e.Width / seg.width
+			src := e.Pix[(sy*e.Width+sx)*4:]
+			alpha := uint32(src[3])
+
+			offset := (y*seg.width + x) * 4
+			for c := 0; c < 3; c++ {
+				seg.pix[offset+c] = uint16(uint32(src[c]) * alpha * 0x101 / 0xff)
+			}
+
+			seg.pix[offset+3] = 0
+		}
+	}
+
+	a.pixMux.Unlock()
+
+	a.stream(seg.id, time.Now())
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/ws281x"
+)
+
+func TestMatrixOrder(t *testing.T) {
+	// 3x2 matrix, wired from the bottom left and snaking upwards:
+	//
+	//  5 4 3
+	//  0 1 2
+	order, err := matrixOrder(config.Matrix{Width: 3, Height: 2, Origin: "bottomLeft", Wiring: "serpentine"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 4, 3, 0, 1, 2}, order)
+
+	//  2 1 0
+	//  5 4 3
+	order, err = matrixOrder(config.Matrix{Width: 3, Height: 2, Origin: "topRight"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1, 0, 5, 4, 3}, order)
+
+	_, err = matrixOrder(config.Matrix{Width: 3, Height: 2, Wiring: "zigzag"})
+	assert.NotNil(t, err)
+}
+
+func TestSetFrame(t *testing.T) {
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{
+				Id:     0,
+				Curve:  "linear",
+				Matrix: &config.Matrix{Width: 2, Height: 2, Wiring: "serpentine"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, a.leds)
+
+	// a 4x2 image is scaled down to the 2x2 matrix
+	a.HandleSetFrameEvent(event.SetFrameEvent{
+		SegmentId: 0,
+		Width:     4,
+		Height:    2,
+		Pix: []byte{
+			1, 0, 0, 255, 0, 0, 0, 255, 2, 0, 0, 255, 0, 0, 0, 255,
+			3, 0, 0, 255, 0, 0, 0, 255, 4, 0, 0, 255, 0, 0, 0, 255,
+		},
+	})
+
+	a.flush()
+	assert.Nil(t, a.Driver().Render())
+
+	var reds []uint8
+	for _, c := range a.Driver().(*ws281x.Virtual).Frame() {
+		reds = append(reds, uint8(c>>16))
+	}
+
+	// the second row is wired backwards
+	assert.Equal(t, []uint8{1, 2, 4, 3}, reds)
+}
+
+func TestMatrixSize(t *testing.T) {
+	for _, m := range []config.Matrix{
+		{Width: 0, Height: 4},
+		{Width: 4, Height: -1},
+		{Width: 100000, Height: 100000},
+		{Width: 1 << 30, Height: 1 << 30},
+		{Width: maxChannelLeds, Height: 2},
+	} {
+		m := m
+
+		_, err := New(config.Config{
+			StripType: "rgb",
+			Driver:    "virtual",
+			Segments:  []config.Segment{{Id: 0, Matrix: &m}},
+		})
+		assert.EqualError(t, err, "invalid matrix size", "%dx%d", m.Width, m.Height)
+	}
+}
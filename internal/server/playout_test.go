@@ -0,0 +1,31 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+)
+
+func TestScheduleLead(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	frame := func(at time.Time) event.SetLedsEvent {
+		return event.SetLedsEvent{
+			SegmentId: 0,
+			Timestamp: at.UnixNano(),
+			Bits:      16,
+			Pix:       []byte{0xff, 0xff, 0, 0, 0, 0},
+		}
+	}
+
+	// frames from a clock that is far ahead are dropped rather than played
+	a.schedule([]event.SetLedsEvent{frame(time.Now().Add(time.Minute))})
+	assert.Equal(t, 0, a.playout.Len())
+	assert.Equal(t, []uint16{0, 0, 0, 0}, a.segments[0].pix)
+
+	a.schedule([]event.SetLedsEvent{frame(time.Now().Add(time.Second))})
+	assert.Equal(t, 1, a.playout.Len())
+}
@@ -0,0 +1,258 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"ledctl3/internal/server/config"
+)
+
+const (
+	defaultVoltage        = 5
+	defaultChannelCurrent = 20
+	defaultIdleCurrent    = 1
+
+	// powerRelease is how long it takes for the brightness to recover fully
+	// after it has been limited.
+	powerRelease = 2 * time.Second
+)
+
+// Power models the current drawn by the strip.
+type Power struct {
+	// Voltage of the supply, used to convert current to watts.
+	Voltage float64
+	// ChannelCurrent is the current in mA drawn by a single color channel of
+	// an LED at full output.
+	ChannelCurrent float64
+	// IdleCurrent is the current in mA drawn by an LED that is off.
+	IdleCurrent float64
+
+	// domains are the sets of LEDs that share a current limit, e.g. the whole
+	// strip, a segment or the LEDs powered by an injection point.
+	domains []*powerDomain
+
+	current   float64
+	watts     float64
+	wattHours float64
+	updated   time.Time
+}
+
+type powerDomain struct {
+	leds []int
+	// max is the current limit of the domain in mA.
+	max float64
+	// scale is the brightness factor currently applied to the domain.
+	scale float64
+}
+
+// PowerState is a snapshot of the estimated power usage.
+type PowerState struct {
+	Current   float64 `json:"current"`
+	Watts     float64 `json:"watts"`
+	WattHours float64 `json:"wattHours"`
+	Limited   bool    `json:"limited"`
+}
+
+func validatePower(c config.Config) error {
+	p := c.Power
+
+	if p.Voltage < 0 || p.ChannelCurrent < 0 || p.IdleCurrent < 0 || p.MaxCurrent < 0 {
+		return errors.New("power settings must not be negative")
+	}
+
+	_, counts, err := mapSegments(c.Segments, channelConfigs(c))
+	if err != nil {
+		return err
+	}
+
+	leds := 0
+	for _, n := range counts {
+		leds += n
+	}
+
+	for _, inj := range p.Injections {
+		if inj.Start < 0 || inj.Start > inj.End {
+			return errors.New("invalid power injection range")
+		}
+
+		if inj.End >= leds {
+			return errors.New("power injection index out of range")
+		}
+
+		if inj.MaxCurrent <= 0 {
+			return errors.New("power injection current limit must be positive")
+		}
+	}
+
+	for _, seg := range c.Segments {
+		if seg.MaxCurrent < 0 {
+			return errors.New("segment current limit must not be negative")
+		}
+	}
+
+	return nil
+}
+
+func (a *Application) applyPowerConfig(c config.Config) {
+	p := &Power{
+		Voltage:        c.Power.Voltage,
+		ChannelCurrent: c.Power.ChannelCurrent,
+		IdleCurrent:    c.Power.IdleCurrent,
+		updated:        time.Now(),
+	}
+
+	if p.Voltage == 0 {
+		p.Voltage = defaultVoltage
+	}
+
+	if p.ChannelCurrent == 0 {
+		p.ChannelCurrent = defaultChannelCurrent
+	}
+
+	if p.IdleCurrent == 0 {
+		p.IdleCurrent = defaultIdleCurrent
+	}
+
+	if c.Power.MaxCurrent > 0 {
+		p.domains = append(p.domains, &powerDomain{
+			leds:  ledRange(0, a.leds-1),
+			max:   c.Power.MaxCurrent,
+			scale: 1,
+		})
+	}
+
+	for _, seg := range c.Segments {
+		if seg.MaxCurrent == 0 {
+			continue
+		}
+
+		s := a.segments[seg.Id]
+
+		p.domains = append(p.domains, &powerDomain{
+			leds:  s.indices,
+			max:   seg.MaxCurrent,
+			scale: 1,
+		})
+	}
+
+	for _, inj := range c.Power.Injections {
+		p.domains = append(p.domains, &powerDomain{
+			leds:  ledRange(inj.Start, inj.End),
+			max:   inj.MaxCurrent,
+			scale: 1,
+		})
+	}
+
+	a.power = p
+}
+
+// ledRange returns the indices of the LEDs between start and end inclusive.
+func ledRange(start, end int) []int {
+	leds := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		leds = append(leds, i)
+	}
+
+	return leds
+}
+
+// ledCurrent estimates the current drawn by an LED with the given output.
+func (p *Power) ledCurrent(pix []uint16) float64 {
+	sum := float64(pix[0]) + float64(pix[1]) + float64(pix[2]) + float64(pix[3])
+
+	return p.IdleCurrent + sum/0xffff*p.ChannelCurrent
+}
+
+// limit scales down the output of the domains that exceed their current
+// limit. The brightness drops immediately when a limit is exceeded, and then
+// recovers gradually over powerRelease once the load allows it. It also
+// updates the power usage estimate.
+func (p *Power) limit(pix []uint16) {
+	now := time.Now()
+	elapsed := now.Sub(p.updated)
+
+	// the previous estimate was valid until now
+	p.wattHours += p.watts * elapsed.Hours()
+	p.updated = now
+
+	leds := len(pix) / 4
+
+	if len(p.domains) > 0 {
+		scales := make([]float64, leds)
+		for i := range scales {
+			scales[i] = 1
+		}
+
+		for _, d := range p.domains {
+			var idle, active float64
+			for _, i := range d.leds {
+				if i >= leds {
+					continue
+				}
+
+				c := p.ledCurrent(pix[i*4 : i*4+4])
+
+				idle += p.IdleCurrent
+				active += c - p.IdleCurrent
+			}
+
+			target := 1.0
+			if idle+active > d.max && active > 0 {
+				target = math.Max(0, (d.max-idle)/active)
+			}
+
+			if target < d.scale {
+				d.scale = target
+			} else {
+				d.scale = math.Min(target, d.scale+elapsed.Seconds()/powerRelease.Seconds())
+			}
+
+			for _, i := range d.leds {
+				if i < leds {
+					scales[i] = math.Min(scales[i], d.scale)
+				}
+			}
+		}
+
+		for i, s := range scales {
+			if s == 1 {
+				continue
+			}
+
+			for j := i * 4; j < i*4+4; j++ {
+				pix[j] = uint16(float64(pix[j]) * s)
+			}
+		}
+	}
+
+	var current float64
+	for i := 0; i < leds; i++ {
+		current += p.ledCurrent(pix[i*4 : i*4+4])
+	}
+
+	p.current = current
+	p.watts = current / 1000 * p.Voltage
+}
+
+// limiting reports whether the output of any domain is scaled down. The
+// brightness only recovers while frames are rendered.
+func (p *Power) limiting() bool {
+	for _, d := range p.domains {
+		if d.scale < 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// State returns the current power usage estimate.
+func (p *Power) State() PowerState {
+	return PowerState{
+		Current:   p.current,
+		Watts:     p.watts,
+		WattHours: p.wattHours + p.watts*time.Since(p.updated).Hours(),
+		Limited:   p.limiting(),
+	}
+}
@@ -0,0 +1,102 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/server/config"
+)
+
+func TestPowerInjectionRange(t *testing.T) {
+	newStrip := func(inj config.Injection) error {
+		_, err := New(config.Config{
+			StripType:  "rgb",
+			Brightness: 255,
+			Driver:     "virtual",
+			Segments: []config.Segment{
+				{Id: 0, Leds: 10},
+			},
+			Power: config.Power{
+				Injections: []config.Injection{inj},
+			},
+		})
+
+		return err
+	}
+
+	assert.Nil(t, newStrip(config.Injection{Start: 0, End: 9, MaxCurrent: 100}))
+	assert.NotNil(t, newStrip(config.Injection{Start: 0, End: 10, MaxCurrent: 100}))
+	assert.NotNil(t, newStrip(config.Injection{Start: 5, End: 1 << 40, MaxCurrent: 100}))
+	assert.NotNil(t, newStrip(config.Injection{Start: 5, End: 4, MaxCurrent: 100}))
+}
+
+func newPower(domains ...*powerDomain) *Power {
+	return &Power{
+		Voltage:        5,
+		ChannelCurrent: 20,
+		IdleCurrent:    1,
+		domains:        domains,
+		updated:        time.Now(),
+	}
+}
+
+func TestPowerEstimate(t *testing.T) {
+	p := newPower(&powerDomain{leds: []int{0, 1}, max: 1000, scale: 1})
+
+	// full white on all four channels of one LED, the other one off
+	pix := []uint16{0xffff, 0xffff, 0xffff, 0xffff, 0, 0, 0, 0}
+	p.limit(pix)
+
+	state := p.State()
+	assert.InDelta(t, 82, state.Current, 1e-9)
+	assert.InDelta(t, 0.41, state.Watts, 1e-9)
+	assert.False(t, state.Limited)
+	assert.Equal(t, []uint16{0xffff, 0xffff, 0xffff, 0xffff, 0, 0, 0, 0}, pix)
+}
+
+func TestPowerLimit(t *testing.T) {
+	// the first LED is fed by an injection point with a low limit, the second
+	// one isn't limited
+	d := &powerDomain{leds: []int{0}, max: 31, scale: 1}
+	p := newPower(d, &powerDomain{leds: []int{1}, max: 1000, scale: 1})
+
+	white := func() []uint16 {
+		return []uint16{0xffff, 0xffff, 0xffff, 0, 0xffff, 0xffff, 0xffff, 0}
+	}
+
+	// 61mA over a 31mA budget: only the channels above idle are scaled, down
+	// to half at once
+	pix := white()
+	p.limit(pix)
+
+	assert.InDelta(t, 0.5, d.scale, 1e-9)
+	assert.Equal(t, uint16(0x7fff), pix[0])
+	assert.Equal(t, uint16(0xffff), pix[4])
+	assert.InDelta(t, 31+61, p.State().Current, 0.01)
+	assert.True(t, p.State().Limited)
+
+	// once the load drops, the brightness recovers gradually over
+	// powerRelease
+	p.updated = time.Now().Add(-powerRelease / 4)
+	p.limit(make([]uint16, 8))
+
+	assert.InDelta(t, 0.75, d.scale, 0.01)
+	assert.True(t, p.State().Limited)
+
+	p.updated = time.Now().Add(-powerRelease)
+	pix = white()
+	pix[0], pix[1], pix[2] = 0, 0, 0
+	p.limit(pix)
+
+	assert.Equal(t, 1.0, d.scale)
+	assert.False(t, p.State().Limited)
+
+	// the recovery is capped by the load, too
+	p.updated = time.Now().Add(-powerRelease)
+	pix = white()
+	p.limit(pix)
+
+	assert.InDelta(t, 0.5, d.scale, 1e-9)
+}
@@ -0,0 +1,387 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+// LoadScenes loads the scenes stored next to the config file. Scenes that are
+// saved or deleted afterwards are stored as well.
+func (a *Application) LoadScenes() error {
+	scenes, err := config.LoadScenes()
+	if err != nil {
+		return err
+	}
+
+	a.sceneMux.Lock()
+	defer a.sceneMux.Unlock()
+
+	a.scenes = scenes
+	a.persistScenes = true
+
+	return nil
+}
+
+// Scenes returns the saved scenes.
+func (a *Application) Scenes() []config.Scene {
+	a.sceneMux.Lock()
+	defer a.sceneMux.Unlock()
+
+	scenes := make([]config.Scene, len(a.scenes))
+	copy(scenes, a.scenes)
+
+	return scenes
+}
+
+// SaveScene saves the current state of the given segments or groups as a scene,
+// replacing any scene of the same name. Without ids, all segments are saved.
+func (a *Application) SaveScene(name string, ids []int) error {
+	if name == "" {
+		return errors.New("scene name must not be empty")
+	}
+
+	segs, err := a.sceneSegments(ids)
+	if err != nil {
+		return err
+	}
+
+	scene := config.Scene{Name: name}
+
+	a.pixMux.Lock()
+	for _, seg := range segs {
+		st := a.states[seg.id]
+
+		brightness := st.brightness
+		if st.dim != nil {
+			brightness = st.dim.to
+		}
+
+		scene.Segments = append(scene.Segments, config.SceneSegment{
+			Id:         seg.id,
+			On:         st.off == nil,
+			Brightness: int(math.Round(brightness * 255)),
+			Look:       st.look,
+		})
+	}
+	a.pixMux.Unlock()
+
+	a.sceneMux.Lock()
+	defer a.sceneMux.Unlock()
+
+	replaced := false
+	for i, s := range a.scenes {
+		if s.Name == name {
+			a.scenes[i] = scene
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		a.scenes = append(a.scenes, scene)
+	}
+
+	return a.storeScenes()
+}
+
+// sceneSegments returns the segments with the given ids, or all segments that
+// aren't mirrors, sorted by id.
+func (a *Application) sceneSegments(ids []int) ([]Segment, error) {
+	var segs []Segment
+
+	if len(ids) == 0 {
+		for _, seg := range a.segments {
+			if seg.mirror == nil {
+				segs = append(segs, seg)
+			}
+		}
+	}
+
+	added := map[int]bool{}
+
+	for _, id := range ids {
+		resolved, err := a.resolve(id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, seg := range resolved {
+			if !added[seg.id] {
+				segs = append(segs, seg)
+				added[seg.id] = true
+			}
+		}
+	}
+
+	sort.Slice(segs, func(i, j int) bool {
+		return segs[i].id < segs[j].id
+	})
+
+	return segs, nil
+}
+
+// ApplyScene restores the state of the segments of a scene, with a transition
+// of the given duration in seconds.
+func (a *Application) ApplyScene(name string, transition *float64) error {
+	scene, ok := a.scene(name)
+	if !ok {
+		return fmt.Errorf("scene doesn't exist: %s", name)
+	}
+
+	// what each segment of the scene shows, which is nil for segments that
+	// keep their colors
+	looks := make([]*snapshot, len(scene.Segments))
+
+	for i, s := range scene.Segments {
+		seg, ok := a.segments[s.Id]
+		if !ok || seg.mirror != nil {
+			return fmt.Errorf("scene segment doesn't exist: %d", s.Id)
+		}
+
+		if s.Brightness < 0 || s.Brightness > 255 {
+			return errors.New("brightness must be between 0 and 255")
+		}
+
+		if s.Look == nil {
+			continue
+		}
+
+		pix, anim, err := parseLook(*s.Look, seg.width, seg.height)
+		if err != nil {
+			return err
+		}
+
+		looks[i] = &snapshot{pix: pix, anim: anim, look: s.Look}
+	}
+
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	d := a.transitionDuration(transition)
+	now := time.Now()
+
+	for i, s := range scene.Segments {
+		seg := a.segments[s.Id]
+		st := a.states[seg.id]
+		to := looks[i]
+
+		a.setBrightness(st, float64(s.Brightness)/255, d, now)
+
+		switch {
+		case s.On && to != nil:
+			a.transition(seg, st, to.pix, to.anim, d, now)
+			st.look = to.look
+		case s.On && st.off != nil:
+			off := st.off
+
+			a.transition(seg, st, off.pix, off.anim, d, now)
+			st.look = off.look
+		case !s.On:
+			if to == nil {
+				if st.off != nil {
+					continue
+				}
+
+				to = a.snapshot(seg, st)
+			}
+
+			if st.off == nil {
+				a.transition(seg, st, make([]uint16, seg.leds*4), nil, d, now)
+			}
+
+			// turning the segment on shows the look of the scene
+			st.off = to
+			st.look = to.look
+		}
+	}
+
+	return nil
+}
+
+// DeleteScene deletes a saved scene.
+func (a *Application) DeleteScene(name string) error {
+	a.sceneMux.Lock()
+	defer a.sceneMux.Unlock()
+
+	for i, s := range a.scenes {
+		if s.Name == name {
+			a.scenes = append(a.scenes[:i], a.scenes[i+1:]...)
+			return a.storeScenes()
+		}
+	}
+
+	return fmt.Errorf("scene doesn't exist: %s", name)
+}
+
+func (a *Application) scene(name string) (config.Scene, bool) {
+	a.sceneMux.Lock()
+	defer a.sceneMux.Unlock()
+
+	for _, s := range a.scenes {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return config.Scene{}, false
+}
+
+// storeScenes writes the scenes to disk if they were loaded from it. sceneMux
+// must be held.
+func (a *Application) storeScenes() error {
+	if !a.persistScenes {
+		return nil
+	}
+
+	return config.SaveScenes(a.scenes)
+}
+
+func (a *Application) HandleSaveSceneEvent(e event.SaveSceneEvent) {
+	err := a.SaveScene(e.Name, e.Segments)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (a *Application) HandleApplySceneEvent(e event.ApplySceneEvent) {
+	err := a.ApplyScene(e.Name, e.Transition)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (a *Application) HandleDeleteSceneEvent(e event.DeleteSceneEvent) {
+	err := a.DeleteScene(e.Name)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// HandleListScenesEvent replies with the names of the saved scenes and the
+// segments they contain.
+func (a *Application) HandleListScenesEvent(wsconn *websocket.Conn) {
+	scenes := a.Scenes()
+
+	reply := event.ScenesEvent{
+		Event:  event.Scenes,
+		Scenes: make([]event.ScenesEventScene, len(scenes)),
+	}
+
+	for i, s := range scenes {
+		ids := make([]int, len(s.Segments))
+		for j, seg := range s.Segments {
+			ids[j] = seg.Id
+		}
+
+		reply.Scenes[i] = event.ScenesEventScene{Name: s.Name, Segments: ids}
+	}
+
+	b, err := json.Marshal(reply)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = wsconn.WriteMessage(websocket.TextMessage, b)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// HandleScenes serves the scenes:
+//
+//	GET    /scenes              lists the scenes
+//	GET    /scenes/{name}       responds with a scene
+//	PUT    /scenes/{name}       saves a scene, optionally of {"segments": [...]}
+//	DELETE /scenes/{name}       deletes a scene
+//	POST   /scenes/{name}/apply applies a scene, optionally with {"transition": s}
+func (a *Application) HandleScenes(w http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, "/scenes"), "/")
+
+	if path == "" {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, a.Scenes())
+		return
+	}
+
+	name, action, _ := strings.Cut(path, "/")
+
+	var err error
+
+	switch {
+	case action == "apply" && req.Method == http.MethodPost:
+		var e event.ApplySceneEvent
+		if err = decodeBody(req, &e); err == nil {
+			err = a.ApplyScene(name, e.Transition)
+		}
+	case action != "":
+		http.NotFound(w, req)
+		return
+	case req.Method == http.MethodGet:
+		scene, ok := a.scene(name)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		writeJSON(w, scene)
+		return
+	case req.Method == http.MethodPut:
+		var e event.SaveSceneEvent
+		if err = decodeBody(req, &e); err == nil {
+			err = a.SaveScene(name, e.Segments)
+		}
+	case req.Method == http.MethodDelete:
+		err = a.DeleteScene(name)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if action == "apply" {
+		a.render()
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeBody decodes an optional JSON request body.
+func decodeBody(req *http.Request, v any) error {
+	err := json.NewDecoder(req.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,59 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+func TestScenes(t *testing.T) {
+	a, v := newVirtual(t, false)
+
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "#ff000000"})
+	a.HandleSetBrightnessEvent(event.SetBrightnessEvent{SegmentId: 0, Brightness: 128})
+	assert.Nil(t, a.SaveScene("reading", nil))
+
+	assert.Equal(t, []config.Scene{{
+		Name: "reading",
+		Segments: []config.SceneSegment{
+			{Id: 0, On: true, Brightness: 128, Look: &config.Look{Color: "#ff000000"}},
+		},
+	}}, a.Scenes())
+
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.Rainbow})
+	a.HandleSetBrightnessEvent(event.SetBrightnessEvent{SegmentId: 0, Brightness: 255})
+	a.HandleTurnOffEvent(event.TurnOffEvent{SegmentId: 0})
+
+	// the scene turns the segment back on with its color and brightness
+	assert.Nil(t, a.ApplyScene("reading", nil))
+	assert.Nil(t, a.states[0].off)
+	assert.Nil(t, a.states[0].anim)
+	assert.Equal(t, []uint16{0xffff, 0, 0, 0}, a.segments[0].pix)
+
+	a.flush()
+	assert.Nil(t, a.Driver().Render())
+	assert.Equal(t, uint32(128), v.Frame()[0]>>16)
+
+	assert.Nil(t, a.DeleteScene("reading"))
+	assert.NotNil(t, a.ApplyScene("reading", nil))
+}
+
+func TestSceneOff(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.Christmas})
+	a.HandleTurnOffEvent(event.TurnOffEvent{SegmentId: 0})
+	assert.Nil(t, a.SaveScene("night", []int{0}))
+
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "#0000ff00"})
+
+	// the segment is turned off, and turning it on shows the effect
+	assert.Nil(t, a.ApplyScene("night", nil))
+	assert.Equal(t, []uint16{0, 0, 0, 0}, a.segments[0].pix)
+
+	a.HandleTurnOnEvent(event.TurnOnEvent{SegmentId: 0})
+	assert.Equal(t, effectAnimation(event.Christmas), a.states[0].anim)
+}
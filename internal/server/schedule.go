@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/schedule"
+)
+
+// parseTask returns the trigger of a scheduled task and the events it
+// processes when it runs.
+func parseTask(t config.Task, loc *config.Location) (schedule.Trigger, []event.Event, error) {
+	var trigger schedule.Trigger
+
+	switch {
+	case t.Cron != "" && t.Sun != "":
+		return nil, nil, errors.New("task must be triggered by either cron or the sun")
+	case t.Cron != "":
+		c, err := schedule.ParseCron(t.Cron)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		trigger = c
+	case t.Sun != "":
+		if loc == nil {
+			return nil, nil, errors.New("location is required for sunrise and sunset")
+		}
+
+		offset := time.Duration(t.Offset * float64(time.Minute))
+
+		s, err := schedule.NewSun(schedule.SunEvent(t.Sun), loc.Latitude, loc.Longitude, offset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		trigger = s
+	default:
+		return nil, nil, errors.New("task must have a trigger")
+	}
+
+	actions := 0
+	for _, set := range []bool{t.Scene != "", t.Event != nil, t.Brightness != nil} {
+		if set {
+			actions++
+		}
+	}
+
+	if actions != 1 {
+		return nil, nil, errors.New("task must either apply a scene, process an event or change the brightness")
+	}
+
+	switch {
+	case t.Scene != "":
+		return trigger, []event.Event{event.ApplySceneEvent{
+			Event:      event.ApplyScene,
+			Name:       t.Scene,
+			Transition: t.Transition,
+		}}, nil
+	case t.Brightness != nil:
+		if *t.Brightness < 0 || *t.Brightness > 255 {
+			return nil, nil, errors.New("brightness must be between 0 and 255")
+		}
+
+		return trigger, []event.Event{event.SetBrightnessEvent{
+			Event:      event.SetBrightness,
+			SegmentId:  t.SegmentId,
+			Brightness: *t.Brightness,
+			Transition: t.Transition,
+		}}, nil
+	}
+
+	events, err := event.Parse(t.Event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return trigger, events, nil
+}
+
+// parseSchedule returns the scheduled tasks as jobs that process their events.
+func (a *Application) parseSchedule(c config.Config) ([]schedule.Job, error) {
+	jobs := make([]schedule.Job, len(c.Schedule))
+
+	for i, t := range c.Schedule {
+		trigger, events, err := parseTask(t, c.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs[i] = schedule.Job{
+			Trigger: trigger,
+			Run: func() {
+				a.ProcessEvents(events...)
+			},
+		}
+	}
+
+	return jobs, nil
+}
+
+// runSchedule runs the scheduled tasks until ctx is done.
+func (a *Application) runSchedule(ctx context.Context) {
+	if len(a.jobs) == 0 {
+		return
+	}
+
+	schedule.New(a.clock, a.jobs...).Run(ctx)
+}
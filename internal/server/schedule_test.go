@@ -0,0 +1,128 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/schedule"
+)
+
+// fakeClock is a clock that only moves when it is set.
+type fakeClock struct {
+	mux     sync.Mutex
+	now     time.Time
+	waiters map[chan time.Time]time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiters: map[chan time.Time]time.Time{}}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) At(t time.Time) <-chan time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if !t.After(c.now) {
+		ch <- c.now
+	} else {
+		c.waiters[ch] = t
+	}
+
+	return ch
+}
+
+// waiting reports whether anyone waits for the clock.
+func (c *fakeClock) waiting() bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return len(c.waiters) > 0
+}
+
+func (c *fakeClock) set(now time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.now = now
+
+	for ch, t := range c.waiters {
+		if !t.After(now) {
+			ch <- now
+			delete(c.waiters, ch)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	brightness := 64
+
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{Id: 0, Leds: 1},
+		},
+		Location: &config.Location{Latitude: 52.52, Longitude: 13.405},
+		Schedule: []config.Task{
+			{Cron: "0 23 * * *", Brightness: &brightness},
+			{Sun: "sunset", Offset: -30, Event: json.RawMessage(`{"event": "setColor", "segmentId": 0, "color": "#ff000000"}`)},
+		},
+	})
+	assert.Nil(t, err)
+
+	clock := newFakeClock(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	a.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.runSchedule(ctx)
+
+	assert.Eventually(t, clock.waiting, time.Second, time.Millisecond)
+
+	pix := func() []uint16 {
+		a.pixMux.Lock()
+		defer a.pixMux.Unlock()
+
+		return append([]uint16{}, a.segments[0].pix...)
+	}
+
+	// half an hour before sunset
+	_, sunset, _ := schedule.SunTimes(clock.Now(), 52.52, 13.405)
+	clock.set(sunset.Add(-30 * time.Minute))
+	assert.Eventually(t, func() bool {
+		return pix()[0] == 0xffff
+	}, time.Second, time.Millisecond)
+
+	assert.Eventually(t, clock.waiting, time.Second, time.Millisecond)
+	clock.set(time.Date(2024, 6, 21, 23, 0, 0, 0, time.UTC))
+	assert.Eventually(t, func() bool {
+		a.pixMux.Lock()
+		defer a.pixMux.Unlock()
+
+		return a.states[0].brightness == 64.0/255
+	}, time.Second, time.Millisecond)
+
+	// a task that is due for too long is skipped
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "#00000000"})
+	assert.Eventually(t, clock.waiting, time.Second, time.Millisecond)
+	clock.set(time.Date(2024, 6, 22, 21, 0, 0, 0, time.UTC))
+	assert.Eventually(t, clock.waiting, time.Second, time.Millisecond)
+	assert.Equal(t, uint16(0), pix()[0])
+}
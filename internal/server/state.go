@@ -0,0 +1,114 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// State is a snapshot of the server's state.
+type State struct {
+	Leds       int            `json:"leds"`
+	StripType  string         `json:"stripType"`
+	Brightness int            `json:"brightness"`
+	Channels   []ChannelState `json:"channels"`
+	Segments   []SegmentState `json:"segments"`
+	Power      PowerState     `json:"power"`
+}
+
+type ChannelState struct {
+	GpioPin    int    `json:"gpioPin"`
+	StripType  string `json:"stripType"`
+	Brightness int    `json:"brightness"`
+	Leds       int    `json:"leds"`
+}
+
+type SegmentState struct {
+	Id      int `json:"id"`
+	Channel int `json:"channel"`
+	Leds    int `json:"leds"`
+	Width   int `json:"width,omitempty"`
+	Height  int `json:"height,omitempty"`
+}
+
+func (a *Application) State() State {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	segs := make([]SegmentState, 0, len(a.segments))
+	for _, seg := range a.segments {
+		s := SegmentState{
+			Id:      seg.id,
+			Channel: seg.channel,
+			Leds:    seg.leds,
+		}
+
+		if seg.height > 1 {
+			s.Width, s.Height = seg.width, seg.height
+		}
+
+		segs = append(segs, s)
+	}
+
+	sort.Slice(segs, func(i, j int) bool {
+		return segs[i].Id < segs[j].Id
+	})
+
+	chans := make([]ChannelState, len(a.channels))
+	for i, ch := range a.channels {
+		chans[i] = ChannelState{
+			GpioPin:    ch.gpioPin,
+			StripType:  ch.stripType,
+			Brightness: ch.brightness,
+			Leds:       ch.leds,
+		}
+	}
+
+	return State{
+		Leds:       a.leds,
+		StripType:  a.channels[0].stripType,
+		Brightness: a.channels[0].brightness,
+		Channels:   chans,
+		Segments:   segs,
+		Power:      a.power.State(),
+	}
+}
+
+// HandleState responds with the server's state as JSON.
+func (a *Application) HandleState(w http.ResponseWriter, _ *http.Request) {
+	b, err := json.Marshal(a.State())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// HandleMetrics responds with the server's metrics in the Prometheus text
+// exposition format.
+func (a *Application) HandleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s := a.State()
+
+	limited := 0
+	if s.Power.Limited {
+		limited = 1
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP ledctl_power_current_milliamps Estimated current draw of the strip.")
+	fmt.Fprintln(w, "# TYPE ledctl_power_current_milliamps gauge")
+	fmt.Fprintf(w, "ledctl_power_current_milliamps %g\n", s.Power.Current)
+	fmt.Fprintln(w, "# HELP ledctl_power_watts Estimated power draw of the strip.")
+	fmt.Fprintln(w, "# TYPE ledctl_power_watts gauge")
+	fmt.Fprintf(w, "ledctl_power_watts %g\n", s.Power.Watts)
+	fmt.Fprintln(w, "# HELP ledctl_energy_watt_hours_total Estimated energy used by the strip.")
+	fmt.Fprintln(w, "# TYPE ledctl_energy_watt_hours_total counter")
+	fmt.Fprintf(w, "ledctl_energy_watt_hours_total %g\n", s.Power.WattHours)
+	fmt.Fprintln(w, "# HELP ledctl_power_limited Whether the brightness is being limited to stay within the power budget.")
+	fmt.Fprintln(w, "# TYPE ledctl_power_limited gauge")
+	fmt.Fprintf(w, "ledctl_power_limited %d\n", limited)
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+	clr "ledctl3/pkg/color"
+)
+
+// textFace is the bitmap font text is rendered with.
+var textFace = basicfont.Face7x13
+
+// textAnimation scrolls text across a matrix segment.
+type textAnimation struct {
+	// mask holds the coverage of the rendered text.
+	mask  *image.Alpha
+	color [4]uint16
+
+	speed     float64
+	direction event.Direction
+	loops     int
+}
+
+// newTextAnimation renders text with the bitmap font.
+func newTextAnimation(text string, c color.Color, speed float64, direction event.Direction, loops int) (*textAnimation, error) {
+	if text == "" {
+		return nil, errors.New("text must not be empty")
+	}
+
+	if speed < 0 {
+		return nil, errors.New("text speed must not be negative")
+	}
+
+	if loops < 0 {
+		return nil, errors.New("text loop count must not be negative")
+	}
+
+	switch direction {
+	case "":
+		direction = event.Left
+	case event.Left, event.Right, event.Up, event.Down:
+	default:
+		return nil, errors.New("invalid text direction")
+	}
+
+	metrics := textFace.Metrics()
+	width := font.MeasureString(textFace, text).Ceil()
+	height := (metrics.Ascent + metrics.Descent).Ceil()
+
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+
+	d := font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: textFace,
+		Dot:  fixed.Point26_6{Y: metrics.Ascent},
+	}
+	d.DrawString(text)
+
+	rgbw := clr.ToRGBW(c)
+
+	return &textAnimation{
+		mask:      mask,
+		color:     [4]uint16{rgbw.R, rgbw.G, rgbw.B, rgbw.W},
+		speed:     speed,
+		direction: direction,
+		loops:     loops,
+	}, nil
+}
+
+// render draws the text at its scroll position. The text enters on one side of
+// the matrix and scrolls until it has left on the other side. Text that doesn't
+// scroll is centered on the matrix.
+func (t *textAnimation) render(elapsed time.Duration, seg Segment) bool {
+	size := t.mask.Bounds().Size()
+
+	// the top left corner of the text on the matrix
+	x := (seg.width - size.X) / 2
+	y := (seg.height - size.Y) / 2
+
+	done := false
+
+	if t.speed > 0 {
+		horizontal := t.direction == event.Left || t.direction == event.Right
+
+		// distance the text scrolls between entering and leaving
+		length := seg.height + size.Y
+		if horizontal {
+			length = seg.width + size.X
+		}
+
+		dist := t.speed * elapsed.Seconds()
+		loop := int(dist) / length
+		pos := int(math.Mod(dist, float64(length)))
+
+		if t.loops > 0 && loop >= t.loops {
+			// the text has scrolled out for the last time
+			pos = length
+			done = true
+		}
+
+		switch t.direction {
+		case event.Left:
+			x = seg.width - pos
+		case event.Right:
+			x = pos - size.X
+		case event.Up:
+			y = seg.height - pos
+		case event.Down:
+			y = pos - size.Y
+		}
+	}
+
+	for py := 0; py < seg.height; py++ {
+		for px := 0; px < seg.width; px++ {
+			a := uint32(t.mask.AlphaAt(px-x, py-y).A)
+
+			offset := (py*seg.width + px) * 4
+			for c := 0; c < 4; c++ {
+				seg.pix[offset+c] = uint16(uint32(t.color[c]) * a / 0xff)
+			}
+		}
+	}
+
+	return done
+}
+
+func (a *Application) HandleSetTextEvent(e event.SetTextEvent) {
+	text := &config.Text{
+		Text:      e.Text,
+		Color:     e.Color,
+		Speed:     e.Speed,
+		Direction: string(e.Direction),
+		Loops:     e.Loops,
+	}
+
+	a.setLook(e.SegmentId, config.Look{Text: text}, e.Transition)
+}
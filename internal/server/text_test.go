@@ -0,0 +1,71 @@
+package application
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+	"ledctl3/internal/server/config"
+)
+
+func newMatrix(t *testing.T, width, height int) *Application {
+	a, err := New(config.Config{
+		StripType:  "rgb",
+		Brightness: 255,
+		Driver:     "virtual",
+		Segments: []config.Segment{
+			{Id: 0, Curve: "linear", Matrix: &config.Matrix{Width: width, Height: height}},
+		},
+	})
+	assert.Nil(t, err)
+
+	return a
+}
+
+// lit returns the number of LEDs of a segment that are on.
+func lit(seg Segment) int {
+	n := 0
+	for i := 0; i < seg.leds; i++ {
+		if seg.pix[i*4] > 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestScrollingText(t *testing.T) {
+	a := newMatrix(t, 16, 16)
+	seg := a.segments[0]
+
+	anim, err := newTextAnimation("Hi", color.White, 10, event.Left, 1)
+	assert.Nil(t, err)
+
+	// the text enters from the right and has scrolled out after the width
+	// of the matrix plus the width of the text
+	assert.False(t, anim.render(0, seg))
+	assert.Equal(t, 0, lit(seg))
+
+	assert.False(t, anim.render(1500*time.Millisecond, seg))
+	assert.Greater(t, lit(seg), 0)
+
+	assert.True(t, anim.render(3*time.Second, seg))
+	assert.Equal(t, 0, lit(seg))
+}
+
+func TestStaticText(t *testing.T) {
+	a := newMatrix(t, 16, 16)
+
+	a.HandleSetTextEvent(event.SetTextEvent{SegmentId: 0, Text: "Hi", Color: "#ff000000"})
+
+	st := a.states[0]
+	assert.NotNil(t, st.anim)
+	assert.Greater(t, lit(a.segments[0]), 0)
+
+	// text that doesn't scroll never ends
+	assert.True(t, a.animate(st.started.Add(time.Hour)))
+	assert.NotNil(t, st.anim)
+}
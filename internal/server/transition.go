@@ -0,0 +1,268 @@
+package application
+
+import (
+	"math"
+	"time"
+
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/color"
+)
+
+// maxTransition is the longest transition that can be requested.
+const maxTransition = 60
+
+// segmentState is the part of a segment's state that changes while rendering.
+type segmentState struct {
+	// streaming is set while the segment shows frames sent by a client and
+	// updated is the time the latest one arrived.
+	streaming bool
+	updated   time.Time
+
+	// anim is the animation shown on the segment, if any, and started is
+	// the time it started.
+	anim    animation
+	started time.Time
+
+	fade *fade
+
+	// look describes what the segment shows if it was set by an event, so
+	// that it can be saved in a scene.
+	look *config.Look
+
+	// off holds what the segment showed before it was turned off, so that
+	// turning it on again restores it.
+	off *snapshot
+
+	// brightness scales the output of the segment from 0 to 1, and dim fades
+	// it to a new brightness. Both are kept when the segment's colors change.
+	brightness float64
+	dim        *dim
+}
+
+// snapshot is what a segment shows: either an animation, or static colors.
+type snapshot struct {
+	pix  []uint16
+	anim animation
+	look *config.Look
+}
+
+// fade crossfades a segment from its colors at the start of the fade to either
+// static colors, or to the frames of its animation if to is nil. Colors are mixed
+// in the Oklab color space so that the fade looks even.
+type fade struct {
+	from     []uint16
+	to       []uint16
+	start    time.Time
+	duration time.Duration
+}
+
+func (f *fade) progress(now time.Time) float64 {
+	return progress(f.start, f.duration, now)
+}
+
+// dim fades the brightness of a segment.
+type dim struct {
+	from     float64
+	to       float64
+	start    time.Time
+	duration time.Duration
+}
+
+// progress returns how far a fade that started at the given time has
+// progressed, from 0 to 1.
+func progress(start time.Time, d time.Duration, now time.Time) float64 {
+	if d <= 0 {
+		return 1
+	}
+
+	p := float64(now.Sub(start)) / float64(d)
+	if p > 1 {
+		return 1
+	}
+
+	return p
+}
+
+// mix writes the colors at progress p of the fade to pix. to is the target
+// colors, which may be pix itself.
+func (f *fade) mix(pix, to []uint16, p float64) {
+	if p >= 1 {
+		copy(pix, to)
+		return
+	} else if p <= 0 {
+		copy(pix, f.from)
+		return
+	}
+
+	for offset := 0; offset < len(pix); offset += 4 {
+		l1, a1, b1 := oklab(f.from[offset], f.from[offset+1], f.from[offset+2])
+		l2, a2, b2 := oklab(to[offset], to[offset+1], to[offset+2])
+
+		r, g, b := fromOklab(l1+(l2-l1)*p, a1+(a2-a1)*p, b1+(b2-b1)*p)
+
+		w1, w2 := float64(f.from[offset+3]), float64(to[offset+3])
+
+		pix[offset] = r
+		pix[offset+1] = g
+		pix[offset+2] = b
+		pix[offset+3] = uint16(w1 + (w2-w1)*p + 0.5)
+	}
+}
+
+func oklab(r, g, b uint16) (float64, float64, float64) {
+	return color.LinearToOklab(
+		color.SRGBToLinear(float64(r)/0xffff),
+		color.SRGBToLinear(float64(g)/0xffff),
+		color.SRGBToLinear(float64(b)/0xffff),
+	)
+}
+
+func fromOklab(l, a, b float64) (uint16, uint16, uint16) {
+	r, g, bb := color.OklabToLinear(l, a, b)
+
+	encode := func(v float64) uint16 {
+		v = math.Max(0, math.Min(1, v))
+		return uint16(math.Round(color.LinearToSRGB(v) * 0xffff))
+	}
+
+	return encode(r), encode(g), encode(bb)
+}
+
+// transitionDuration returns the duration of a transition requested by an
+// event, or the configured default if the event doesn't specify one.
+func (a *Application) transitionDuration(seconds *float64) time.Duration {
+	if seconds == nil {
+		return a.transitionTime
+	}
+
+	s := math.Max(0, math.Min(maxTransition, *seconds))
+
+	return time.Duration(s * float64(time.Second))
+}
+
+// transition changes what a segment shows to either an animation or static
+// colors, crossfading from its current colors over the given duration. pixMux
+// must be held.
+func (a *Application) transition(seg Segment, st *segmentState, to []uint16, anim animation, d time.Duration, now time.Time) {
+	pix := seg.pix
+
+	*st = segmentState{
+		anim:       anim,
+		started:    now,
+		brightness: st.brightness,
+		dim:        st.dim,
+	}
+
+	if d <= 0 {
+		if anim != nil {
+			anim.render(0, seg)
+		} else {
+			copy(pix, to)
+		}
+
+		return
+	}
+
+	st.fade = &fade{
+		from:     make([]uint16, len(pix)),
+		to:       to,
+		start:    now,
+		duration: d,
+	}
+	copy(st.fade.from, pix)
+}
+
+// snapshot returns what a segment is showing, or fading to. pixMux must be
+// held.
+func (a *Application) snapshot(seg Segment, st *segmentState) *snapshot {
+	if st.anim != nil {
+		return &snapshot{anim: st.anim, look: st.look}
+	}
+
+	pix := seg.pix
+	if st.fade != nil {
+		pix = st.fade.to
+	}
+
+	s := &snapshot{pix: make([]uint16, len(pix)), look: st.look}
+	copy(s.pix, pix)
+
+	return s
+}
+
+// setBrightness fades the brightness of a segment from 0 to 1 over the given
+// duration. pixMux must be held.
+func (a *Application) setBrightness(st *segmentState, brightness float64, d time.Duration, now time.Time) {
+	if d <= 0 {
+		st.brightness = brightness
+		st.dim = nil
+		return
+	}
+
+	st.dim = &dim{
+		from:     st.brightness,
+		to:       brightness,
+		start:    now,
+		duration: d,
+	}
+}
+
+// animate advances the idle timeouts, animations and fades of all segments to the
+// given time. It reports whether any LED colors changed.
+func (a *Application) animate(now time.Time) bool {
+	a.pixMux.Lock()
+	defer a.pixMux.Unlock()
+
+	changed := false
+
+	for id, seg := range a.segments {
+		st := a.states[id]
+
+		if d := st.dim; d != nil {
+			p := progress(d.start, d.duration, now)
+			st.brightness = d.from + (d.to-d.from)*p
+
+			if p >= 1 {
+				st.dim = nil
+			}
+
+			changed = true
+		}
+
+		if seg.mirror != nil {
+			continue
+		}
+
+		if st.streaming && seg.idle != nil && now.Sub(st.updated) >= seg.idle.timeout {
+			a.fallback(seg, st, now)
+		}
+
+		pix := seg.pix
+
+		if st.anim != nil {
+			if st.anim.render(now.Sub(st.started), seg) {
+				st.anim = nil
+			}
+
+			changed = true
+		}
+
+		if f := st.fade; f != nil {
+			to := f.to
+			if to == nil {
+				to = pix
+			}
+
+			p := f.progress(now)
+			f.mix(pix, to, p)
+
+			if p >= 1 {
+				st.fade = nil
+			}
+
+			changed = true
+		}
+	}
+
+	return changed
+}
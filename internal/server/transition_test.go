@@ -0,0 +1,56 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+)
+
+func TestTransitionTurnOffOn(t *testing.T) {
+	a, _ := newVirtual(t, true)
+
+	a.HandleSetColorEvent(event.SetColorEvent{SegmentId: 0, Color: "#ff800000"})
+	assert.Equal(t, []uint16{0xffff, 0x8080, 0, 0}, a.segments[0].pix)
+
+	d := 1.0
+	a.HandleTurnOffEvent(event.TurnOffEvent{SegmentId: 0, Transition: &d})
+
+	st := a.states[0]
+	start := st.fade.start
+
+	a.animate(start.Add(500 * time.Millisecond))
+	assert.Greater(t, a.segments[0].pix[0], uint16(0))
+	assert.Less(t, a.segments[0].pix[0], uint16(0xffff))
+
+	a.animate(start.Add(time.Second))
+	assert.Equal(t, []uint16{0, 0, 0, 0}, a.segments[0].pix)
+
+	// turning it on again restores the color
+	a.HandleTurnOnEvent(event.TurnOnEvent{SegmentId: 0, Transition: &d})
+	assert.Nil(t, st.off)
+
+	a.animate(st.fade.start.Add(time.Second))
+	assert.Equal(t, []uint16{0xffff, 0x8080, 0, 0}, a.segments[0].pix)
+}
+
+func TestTransitionEffect(t *testing.T) {
+	a, _ := newVirtual(t, true)
+
+	d := 2.0
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.Christmas, Transition: &d})
+
+	st := a.states[0]
+	assert.Equal(t, effectAnimation(event.Christmas), st.anim)
+
+	// the effect fades in from off, the LED is green at this point
+	a.animate(st.started.Add(time.Second))
+	assert.Greater(t, a.segments[0].pix[1], uint16(0))
+	assert.Less(t, a.segments[0].pix[1], uint16(0xffff))
+
+	a.animate(st.started.Add(2 * time.Second))
+	assert.Nil(t, st.fade)
+	assert.Equal(t, []uint16{0xffff, 0, 0, 0}, a.segments[0].pix)
+}
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"ledctl3/pkg/color"
+)
+
+const (
+	defaultWakeDuration = 30 * time.Minute
+	minWakeDuration     = 10 * time.Minute
+	maxWakeDuration     = 60 * time.Minute
+
+	// the wake-up light starts deep red and ends warm white
+	wakeStartTemperature = 1000
+	wakeEndTemperature   = 2700
+)
+
+// wakeAnimation simulates a sunrise: it ramps from off through deep red and
+// orange to warm white at full brightness, following the colors of a black
+// body as it heats up.
+type wakeAnimation struct {
+	duration time.Duration
+}
+
+// newWakeAnimation creates a wake-up light that takes the given number of
+// seconds, or the default duration for 0.
+func newWakeAnimation(seconds float64) (*wakeAnimation, error) {
+	d := time.Duration(seconds * float64(time.Second))
+	if seconds == 0 {
+		d = defaultWakeDuration
+	}
+
+	if d < minWakeDuration || d > maxWakeDuration {
+		return nil, errors.New("wake-up duration must be between 10 and 60 minutes")
+	}
+
+	return &wakeAnimation{duration: d}, nil
+}
+
+func (w *wakeAnimation) render(t time.Duration, seg Segment) bool {
+	p := math.Min(1, float64(t)/float64(w.duration))
+
+	// the temperature changes evenly in mireds, which looks more even than
+	// in Kelvin
+	start, end := 1e6/wakeStartTemperature, 1e6/wakeEndTemperature
+	r, g, b := color.TemperatureLinear(1e6 / (start + (end-start)*p))
+
+	// the lightness rises evenly, which takes the luminance to the power of 3
+	// in Oklab
+	level := p * p * p
+
+	encode := func(v float64) float64 {
+		return color.LinearToSRGB(v * level)
+	}
+
+	for i := 0; i < len(seg.pix)/4; i++ {
+		setColor(seg.pix, i, encode(r), encode(g), encode(b))
+	}
+
+	return p >= 1
+}
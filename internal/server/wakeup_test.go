@@ -0,0 +1,85 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"ledctl3/internal/pkg/event"
+)
+
+func TestWakeUp(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.WakeUp, Duration: 600})
+
+	st := a.states[0]
+	pix := a.segments[0].pix
+	assert.Equal(t, []uint16{0, 0, 0, 0}, pix)
+
+	// deep red early on
+	a.animate(st.started.Add(2 * time.Minute))
+	assert.Greater(t, pix[0], uint16(0))
+	assert.Less(t, pix[1], pix[0]/4)
+	assert.Equal(t, uint16(0), pix[2])
+
+	// orange halfway
+	a.animate(st.started.Add(5 * time.Minute))
+	assert.Greater(t, pix[1], pix[0]/4)
+	assert.Equal(t, uint16(0), pix[2])
+
+	// warm white at full brightness at the end
+	a.animate(st.started.Add(10 * time.Minute))
+	assert.Nil(t, st.anim)
+	assert.Equal(t, uint16(0xffff), pix[0])
+	assert.Greater(t, pix[1], uint16(0x8000))
+	assert.Greater(t, pix[2], uint16(0))
+}
+
+func TestWakeUpCancel(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.WakeUp, Duration: 600})
+
+	st := a.states[0]
+	a.animate(st.started.Add(5 * time.Minute))
+
+	// a manual brightness change keeps the current colors
+	a.HandleSetBrightnessEvent(event.SetBrightnessEvent{SegmentId: 0, Brightness: 200})
+	assert.Nil(t, st.anim)
+
+	red := a.segments[0].pix[0]
+	a.animate(time.Now().Add(10 * time.Minute))
+	assert.Equal(t, red, a.segments[0].pix[0])
+
+	// the duration is limited to 10-60 minutes
+	_, err := parseEffect("wakeUp", 60)
+	assert.NotNil(t, err)
+}
+
+func TestWakeUpTurnOff(t *testing.T) {
+	a, _ := newVirtual(t, false)
+
+	a.HandleSetEffectEvent(event.SetEffectEvent{SegmentId: 0, Effect: event.WakeUp, Duration: 600})
+
+	st := a.states[0]
+	a.animate(st.started.Add(5 * time.Minute))
+
+	colors := make([]uint16, 4)
+	copy(colors, a.segments[0].pix)
+	assert.Greater(t, colors[0], uint16(0))
+
+	d := 0.0
+	a.HandleTurnOffEvent(event.TurnOffEvent{SegmentId: 0, Transition: &d})
+	a.animate(time.Now())
+	assert.Equal(t, []uint16{0, 0, 0, 0}, a.segments[0].pix)
+
+	// turning on again shows the colors the wake-up light had reached
+	// instead of starting the sunrise over from off
+	a.HandleTurnOnEvent(event.TurnOnEvent{SegmentId: 0, Transition: &d})
+	assert.Nil(t, st.anim)
+
+	a.animate(time.Now().Add(time.Minute))
+	assert.Equal(t, colors, a.segments[0].pix)
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"errors"
+	"math"
+
+	"ledctl3/internal/pkg/strip"
+	"ledctl3/internal/server/config"
+	"ledctl3/pkg/color"
+)
+
+// WhiteMode selects how the white channel of RGBW strips is driven.
+type WhiteMode string
+
+const (
+	// WhiteOff keeps the white channel off.
+	WhiteOff WhiteMode = "off"
+	// WhitePassthrough drives the white channel with the fourth component
+	// sent by the client.
+	WhitePassthrough WhiteMode = "passthrough"
+	// WhiteMin moves the common part of the red, green and blue channels to
+	// the white channel, assuming the white LED is perfectly neutral.
+	WhiteMin WhiteMode = "min"
+	// WhiteTemperature is like WhiteMin, but takes the color temperature of
+	// the white LED into account.
+	WhiteTemperature WhiteMode = "temperature"
+)
+
+var whiteModes = map[string]WhiteMode{
+	"off":         WhiteOff,
+	"passthrough": WhitePassthrough,
+	"min":         WhiteMin,
+	"temperature": WhiteTemperature,
+}
+
+// defaultWhiteTemperature is the color temperature of a typical "natural
+// white" SK6812 LED.
+const defaultWhiteTemperature = 4500
+
+// white extracts the white channel from linear RGB values.
+type white struct {
+	mode WhiteMode
+	// r, g and b are the contribution of the white LED to each channel,
+	// scaled so that the largest is 1.
+	r, g, b float64
+}
+
+func parseWhite(seg config.Segment, stripType string) (white, error) {
+	typ, err := strip.Parse(stripType)
+	if err != nil {
+		return white{}, err
+	}
+
+	w := white{mode: WhiteOff, r: 1, g: 1, b: 1}
+
+	if typ.HasWhite() {
+		w.mode = WhiteMin
+	}
+
+	if seg.WhiteMode != "" {
+		mode, ok := whiteModes[seg.WhiteMode]
+		if !ok {
+			return white{}, errors.New("invalid white mode")
+		}
+
+		w.mode = mode
+	}
+
+	if w.mode == WhiteTemperature {
+		kelvin := seg.WhiteTemperature
+		if kelvin == 0 {
+			kelvin = defaultWhiteTemperature
+		}
+
+		if kelvin < 1667 || kelvin > 25000 {
+			return white{}, errors.New("white temperature out of range")
+		}
+
+		w.r, w.g, w.b = color.TemperatureLinear(kelvin)
+	}
+
+	return w, nil
+}
+
+// extract converts linear RGB values to RGBW. The white LED replaces as much
+// of the red, green and blue light as it can reproduce, so that mixed colors
+// use the more efficient white die instead of all three color dies.
+func (w white) extract(r, g, b uint16) (uint16, uint16, uint16, uint16) {
+	fr, fg, fb := float64(r), float64(g), float64(b)
+
+	// the amount of white light that fits in all three channels
+	ww := float64(0xffff)
+	for _, c := range [][2]float64{{fr, w.r}, {fg, w.g}, {fb, w.b}} {
+		if c[1] > 0 {
+			ww = math.Min(ww, c[0]/c[1])
+		}
+	}
+
+	fr -= ww * w.r
+	fg -= ww * w.g
+	fb -= ww * w.b
+
+	return round16(fr), round16(fg), round16(fb), round16(ww)
+}
+
+func round16(v float64) uint16 {
+	return uint16(math.Max(0, math.Min(0xffff, math.Round(v))))
+}
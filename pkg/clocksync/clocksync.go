@@ -0,0 +1,108 @@
+package clocksync
+
+import (
+	"sync"
+	"time"
+)
+
+// Estimator estimates the offset between a local and a remote clock from
+// NTP-style round-trip measurements. It keeps a window of the most recent
+// samples and trusts the one with the lowest round-trip time, as that is the
+// one least affected by queueing delays.
+type Estimator struct {
+	mux     sync.Mutex
+	size    int
+	samples []sample
+}
+
+type sample struct {
+	offset time.Duration
+	rtt    time.Duration
+}
+
+// New creates an Estimator that considers the last size samples.
+func New(size int) *Estimator {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Estimator{
+		size:    size,
+		samples: make([]sample, 0, size),
+	}
+}
+
+// Add records a round-trip measurement. t0 is the local transmit time, t1 the
+// remote receive time, t2 the remote transmit time and t3 the local receive
+// time.
+func (e *Estimator) Add(t0, t1, t2, t3 time.Time) {
+	s := sample{
+		offset: (t1.Sub(t0) + t2.Sub(t3)) / 2,
+		rtt:    t3.Sub(t0) - t2.Sub(t1),
+	}
+
+	if s.rtt < 0 {
+		// clocks went backwards during the exchange, discard it
+		return
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if len(e.samples) == e.size {
+		copy(e.samples, e.samples[1:])
+		e.samples = e.samples[:len(e.samples)-1]
+	}
+
+	e.samples = append(e.samples, s)
+}
+
+// Synced reports whether at least one sample has been recorded.
+func (e *Estimator) Synced() bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return len(e.samples) > 0
+}
+
+// Offset returns the estimated offset that needs to be added to the local
+// clock to get the remote clock's time.
+func (e *Estimator) Offset() time.Duration {
+	return e.best().offset
+}
+
+// RTT returns the lowest round-trip time in the sample window.
+func (e *Estimator) RTT() time.Duration {
+	return e.best().rtt
+}
+
+// Remote converts a local point in time to the remote clock.
+func (e *Estimator) Remote(t time.Time) time.Time {
+	return t.Add(e.Offset())
+}
+
+// Reset discards all samples, e.g. after reconnecting to a different remote.
+func (e *Estimator) Reset() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.samples = e.samples[:0]
+}
+
+func (e *Estimator) best() sample {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if len(e.samples) == 0 {
+		return sample{}
+	}
+
+	best := e.samples[0]
+	for _, s := range e.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+
+	return best
+}
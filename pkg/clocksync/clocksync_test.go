@@ -0,0 +1,63 @@
+package clocksync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exchange simulates a round trip to a remote clock that is ahead by offset,
+// with the given delays on the way there and back and processing time on the
+// remote.
+func exchange(e *Estimator, t0 time.Time, offset, there, process, back time.Duration) {
+	t1 := t0.Add(there + offset)
+	t2 := t1.Add(process)
+	t3 := t2.Add(back - offset)
+
+	e.Add(t0, t1, t2, t3)
+}
+
+func TestEstimatorSymmetric(t *testing.T) {
+	e := New(4)
+	assert.False(t, e.Synced())
+
+	now := time.Now()
+	exchange(e, now, 100*time.Millisecond, 10*time.Millisecond, 5*time.Millisecond, 10*time.Millisecond)
+
+	assert.True(t, e.Synced())
+	assert.Equal(t, 100*time.Millisecond, e.Offset())
+	// the time spent on the remote doesn't count
+	assert.Equal(t, 20*time.Millisecond, e.RTT())
+	assert.Equal(t, now.Add(100*time.Millisecond), e.Remote(now))
+}
+
+func TestEstimatorAsymmetric(t *testing.T) {
+	e := New(4)
+	now := time.Now()
+
+	// a congested return path skews the offset by half the asymmetry
+	exchange(e, now, 100*time.Millisecond, 5*time.Millisecond, 0, 45*time.Millisecond)
+	assert.Equal(t, 80*time.Millisecond, e.Offset())
+	assert.Equal(t, 50*time.Millisecond, e.RTT())
+
+	// the sample with the lowest round trip time is trusted
+	exchange(e, now, 100*time.Millisecond, 6*time.Millisecond, 0, 4*time.Millisecond)
+	exchange(e, now, 100*time.Millisecond, 30*time.Millisecond, 0, 2*time.Millisecond)
+
+	assert.Equal(t, 101*time.Millisecond, e.Offset())
+	assert.Equal(t, 10*time.Millisecond, e.RTT())
+
+	// old samples leave the window
+	for i := 0; i < 4; i++ {
+		exchange(e, now, 100*time.Millisecond, 20*time.Millisecond, 0, 20*time.Millisecond)
+	}
+
+	assert.Equal(t, 100*time.Millisecond, e.Offset())
+	assert.Equal(t, 40*time.Millisecond, e.RTT())
+
+	// exchanges where the clocks went backwards are discarded
+	e.Reset()
+	e.Add(now, now, now, now.Add(-time.Millisecond))
+	assert.False(t, e.Synced())
+}
@@ -1,34 +1,449 @@
 package color
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"image/color"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
 )
 
+// Form is a way of writing a color as text.
+type Form string
+
+const (
+	// Hex is #RRGGBBWW.
+	Hex Form = "hex"
+	// HexRGB is #RRGGBB.
+	HexRGB Form = "hexRGB"
+	// ShortHex is #RGB, or #RGBW with white.
+	ShortHex Form = "shortHex"
+	// RGB is rgb(r, g, b) with components from 0 to 255.
+	RGB Form = "rgb"
+	// RGBA is rgba(r, g, b, 1).
+	RGBA Form = "rgba"
+	// HSL is hsl(h, s%, l%) with the hue in degrees.
+	HSL Form = "hsl"
+	// HSV is hsv(h, s%, v%) with the hue in degrees.
+	HSV Form = "hsv"
+	// Name is a CSS color name, like rebeccapurple.
+	Name Form = "name"
+	// Kelvin is a color temperature, like 2700K.
+	Kelvin Form = "kelvin"
+)
+
+// RGBW is a color of an RGBW strip with 16-bit channels. W is the separate
+// white LED, not an alpha channel: RGBW colors are always opaque, and their
+// RGBA method leaves white out.
+type RGBW struct {
+	R, G, B, W uint16
+}
+
+func (c RGBW) RGBA() (r, g, b, a uint32) {
+	return uint32(c.R), uint32(c.G), uint32(c.B), 0xffff
+}
+
+// ToRGBW converts a color to RGBW. Colors other than RGBW have no white, and
+// translucent ones are blended with black.
+func ToRGBW(c color.Color) RGBW {
+	if c, ok := c.(RGBW); ok {
+		return c
+	}
+
+	r, g, b, _ := c.RGBA()
+
+	return RGBW{R: uint16(r), G: uint16(g), B: uint16(b)}
+}
+
+// ToString formats a color as #RRGGBBWW.
 func ToString(c color.Color) string {
-	r, g, b, a := c.RGBA()
-	return fmt.Sprintf("#%02x%02x%02x%02x", r>>8, g>>8, b>>8, a>>8)
+	return Format(c, Hex)
+}
+
+// Format writes a color in the given form. Colors that the form can't express
+// exactly, e.g. colors with white in any form but the hex ones, or colors
+// without a name, are written as #RRGGBB, or #RRGGBBWW with white.
+func Format(c color.Color, form Form) string {
+	rgbw := ToRGBW(c)
+	r, g, b, w := uint32(rgbw.R>>8), uint32(rgbw.G>>8), uint32(rgbw.B>>8), uint32(rgbw.W>>8)
+
+	fallback := func() string {
+		if w != 0 {
+			return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, w)
+		}
+
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+
+	if form == Hex {
+		return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, w)
+	}
+
+	if form == ShortHex {
+		short := func(v uint32) bool { return v%0x11 == 0 }
+
+		if !short(r) || !short(g) || !short(b) || !short(w) {
+			return fallback()
+		}
+
+		if w != 0 {
+			return fmt.Sprintf("#%x%x%x%x", r/0x11, g/0x11, b/0x11, w/0x11)
+		}
+
+		return fmt.Sprintf("#%x%x%x", r/0x11, g/0x11, b/0x11)
+	}
+
+	if w != 0 {
+		return fallback()
+	}
+
+	cf := colorful.Color{R: float64(r) / 0xff, G: float64(g) / 0xff, B: float64(b) / 0xff}
+
+	switch form {
+	case RGB:
+		return fmt.Sprintf("rgb(%d, %d, %d)", r, g, b)
+	case RGBA:
+		return fmt.Sprintf("rgba(%d, %d, %d, 1)", r, g, b)
+	case HSL:
+		h, s, l := cf.Hsl()
+		return fmt.Sprintf("hsl(%s, %s%%, %s%%)", number(h), number(s*100), number(l*100))
+	case HSV:
+		h, s, v := cf.Hsv()
+		return fmt.Sprintf("hsv(%s, %s%%, %s%%)", number(h), number(s*100), number(v*100))
+	case Name:
+		if name, ok := colorName(r<<16 | g<<8 | b); ok {
+			return name
+		}
+	case Kelvin:
+		if k, ok := colorTemperature(r, g, b); ok {
+			return fmt.Sprintf("%dK", k)
+		}
+	}
+
+	return fallback()
+}
+
+// number formats a number with up to two decimals.
+func number(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
 }
 
-func FromString(s string) (c color.Color, err error) {
-	clr := make([]byte, 4)
+// colorName returns the first CSS name of a color in alphabetical order, as
+// some colors have two names.
+func colorName(rgb uint32) (string, bool) {
+	var found []string
 
-	n, err := hex.Decode(clr, []byte(strings.TrimLeft(s, "#")))
+	for name, v := range names {
+		if v == rgb {
+			found = append(found, name)
+		}
+	}
+
+	if len(found) == 0 {
+		return "", false
+	}
+
+	sort.Strings(found)
+
+	return found[0], true
+}
+
+// colorTemperature finds the temperature in Kelvin whose color matches an
+// 8-bit color. As many temperatures share an 8-bit color, it returns the
+// roundest of the closest ones.
+func colorTemperature(r, g, b uint32) (int, bool) {
+	var matches []int
+
+	// components may be off by one
+	best := 2
+
+	for k := minTemperature; k <= maxTemperature; k++ {
+		t := Temperature(float64(k))
+
+		d := distance(t.R>>8, r)
+		if dg := distance(t.G>>8, g); dg > d {
+			d = dg
+		}
+		if db := distance(t.B>>8, b); db > d {
+			d = db
+		}
+
+		switch {
+		case d < best:
+			best = d
+			matches = []int{k}
+		case d == best:
+			matches = append(matches, k)
+		}
+	}
+
+	if len(matches) == 0 {
+		return 0, false
+	}
+
+	for _, step := range []int{1000, 500, 100, 50, 10} {
+		for _, k := range matches {
+			if k%step == 0 {
+				return k, true
+			}
+		}
+	}
+
+	return matches[len(matches)/2], true
+}
+
+func distance(a uint16, b uint32) int {
+	d := int(a) - int(b)
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
+
+// FromString parses a color in any of these forms:
+//
+//	#RGB, #RGBW, #RRGGBB, #RRGGBBWW
+//	rgb(r, g, b), rgba(r, g, b, a)
+//	hsl(h, s%, l%), hsla(h, s%, l%, a)
+//	hsv(h, s%, v%), hsva(h, s%, v%, a)
+//	CSS color names like rebeccapurple
+//	color temperatures from 1000K to 25000K
+//
+// The color is always an RGBW. The fourth component of the hex forms is the
+// white channel of RGBW strips, the other forms leave white off. The alpha of
+// the functional forms instead blends the color with black.
+func FromString(s string) (color.Color, error) {
+	c, err := parse(strings.ToLower(strings.TrimSpace(s)))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid color %q: %w", s, err)
+	}
+
+	return c, nil
+}
+
+func parse(s string) (color.Color, error) {
+	if s == "" {
+		return nil, errors.New("empty color")
+	}
+
+	if strings.HasPrefix(s, "#") {
+		return parseHex(s[1:])
+	}
+
+	if strings.HasSuffix(s, ")") {
+		return parseFunction(s)
+	}
+
+	if v, ok := names[s]; ok {
+		return RGBW{
+			R: uint16(v>>16) * 0x101,
+			G: uint16(v>>8&0xff) * 0x101,
+			B: uint16(v&0xff) * 0x101,
+		}, nil
 	}
 
-	if n != 4 {
-		return nil, errors.New("could not parse rgba color")
+	if strings.HasSuffix(s, "k") {
+		return parseKelvin(strings.TrimSuffix(s, "k"))
 	}
 
-	return color.RGBA{
-		R: clr[0],
-		G: clr[1],
-		B: clr[2],
-		A: clr[3],
+	// hex colors used to be accepted without #
+	if _, err := strconv.ParseUint(s, 16, 32); err == nil {
+		return parseHex(s)
+	}
+
+	return nil, errors.New("unknown color name")
+}
+
+func parseHex(s string) (color.Color, error) {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return nil, fmt.Errorf("invalid hex digit %q", c)
+		}
+	}
+
+	v, _ := strconv.ParseUint(s, 16, 32)
+
+	var c [4]uint64
+
+	switch len(s) {
+	case 3:
+		c = [4]uint64{v >> 8 * 0x11, v >> 4 & 0xf * 0x11, v & 0xf * 0x11, 0}
+	case 4:
+		c = [4]uint64{v >> 12 * 0x11, v >> 8 & 0xf * 0x11, v >> 4 & 0xf * 0x11, v & 0xf * 0x11}
+	case 6:
+		c = [4]uint64{v >> 16, v >> 8 & 0xff, v & 0xff, 0}
+	case 8:
+		c = [4]uint64{v >> 24, v >> 16 & 0xff, v >> 8 & 0xff, v & 0xff}
+	default:
+		return nil, errors.New("hex colors must have 3, 4, 6 or 8 digits")
+	}
+
+	return RGBW{
+		R: uint16(c[0]) * 0x101,
+		G: uint16(c[1]) * 0x101,
+		B: uint16(c[2]) * 0x101,
+		W: uint16(c[3]) * 0x101,
 	}, nil
 }
+
+// parseFunction parses the functional forms. Components are separated by
+// commas or spaces, and alpha may also follow a slash.
+func parseFunction(s string) (color.Color, error) {
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return nil, errors.New("missing opening parenthesis")
+	}
+
+	name := strings.TrimSpace(s[:open])
+	body, alphaArg, slash := strings.Cut(s[open+1:len(s)-1], "/")
+
+	var args []string
+	if strings.Contains(body, ",") {
+		for _, arg := range strings.Split(body, ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	} else {
+		args = strings.Fields(body)
+	}
+
+	if slash {
+		args = append(args, strings.TrimSpace(alphaArg))
+	}
+
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("%s() takes 3 or 4 components, got %d", name, len(args))
+	}
+
+	alpha := 1.0
+	if len(args) == 4 {
+		a, err := parseComponent(args[3], 1, "alpha")
+		if err != nil {
+			return nil, err
+		}
+
+		alpha = a
+	}
+
+	var r, g, b float64
+
+	switch name {
+	case "rgb", "rgba":
+		var c [3]float64
+
+		for i, label := range []string{"red", "green", "blue"} {
+			v, err := parseComponent(args[i], 255, label)
+			if err != nil {
+				return nil, err
+			}
+
+			c[i] = v
+		}
+
+		r, g, b = c[0], c[1], c[2]
+	case "hsl", "hsla", "hsv", "hsva":
+		h, err := parseHue(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		third := "lightness"
+		if strings.HasPrefix(name, "hsv") {
+			third = "value"
+		}
+
+		sat, err := parsePercentage(args[1], "saturation")
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := parsePercentage(args[2], third)
+		if err != nil {
+			return nil, err
+		}
+
+		var c colorful.Color
+		if third == "value" {
+			c = colorful.Hsv(h, sat, v)
+		} else {
+			c = colorful.Hsl(h, sat, v)
+		}
+
+		c = c.Clamped()
+		r, g, b = c.R, c.G, c.B
+	default:
+		return nil, fmt.Errorf("unknown color function %s()", name)
+	}
+
+	encode := func(v float64) uint16 {
+		return uint16(math.Round(v * alpha * 0xffff))
+	}
+
+	return RGBW{R: encode(r), G: encode(g), B: encode(b)}, nil
+}
+
+// parseComponent parses a number from 0 to max, or a percentage, into the range
+// 0 to 1.
+func parseComponent(s string, max float64, label string) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		return parsePercentage(s, label)
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", label, s)
+	}
+
+	if v < 0 || v > max {
+		return 0, fmt.Errorf("%s must be between 0 and %g, got %g", label, max, v)
+	}
+
+	return v / max, nil
+}
+
+// parsePercentage parses a percentage, with or without %, into the range 0 to
+// 1.
+func parsePercentage(s string, label string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a percentage, got %q", label, s)
+	}
+
+	if v < 0 || v > 100 {
+		return 0, fmt.Errorf("%s must be between 0%% and 100%%, got %g%%", label, v)
+	}
+
+	return v / 100, nil
+}
+
+// parseHue parses a hue in degrees, which wraps around.
+func parseHue(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "deg"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("hue must be a number of degrees, got %q", s)
+	}
+
+	v = math.Mod(v, 360)
+	if v < 0 {
+		v += 360
+	}
+
+	return v, nil
+}
+
+func parseKelvin(s string) (color.Color, error) {
+	k, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("color temperature must be a number of Kelvin, got %q", s)
+	}
+
+	if k < minTemperature || k > maxTemperature {
+		return nil, fmt.Errorf("color temperature must be between %dK and %dK, got %gK", minTemperature, maxTemperature, k)
+	}
+
+	return ToRGBW(Temperature(k)), nil
+}
@@ -0,0 +1,87 @@
+package color
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColor(t *testing.T) {
+	red := RGBW{R: 0xffff}
+
+	for _, s := range []string{
+		"#f00", "#ff0000", "#ff000000", "ff000000", "#F00",
+		"rgb(255, 0, 0)", "rgb(100% 0% 0%)", "rgba(255, 0, 0, 1)", "rgb(255 0 0 / 100%)",
+		"hsl(0, 100%, 50%)", "hsla(360deg, 100%, 50%, 1)", "hsv(0, 100%, 100%)",
+		"red", " Red ",
+	} {
+		c, err := FromString(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, red, c, s)
+	}
+
+	// the fourth hex component is white, and the color stays opaque
+	c, err := FromString("#0000ff80")
+	assert.Nil(t, err)
+	assert.Equal(t, RGBW{B: 0xffff, W: 0x8080}, c)
+	assert.Equal(t, color.RGBA64{B: 0xffff, A: 0xffff}, color.RGBA64Model.Convert(c))
+
+	// alpha blends with black
+	c, err = FromString("rgba(255, 0, 0, 0.5)")
+	assert.Nil(t, err)
+	assert.Equal(t, RGBW{R: 0x8000}, c)
+
+	c, err = FromString("2700K")
+	assert.Nil(t, err)
+	assert.Equal(t, Temperature(2700).R, c.(RGBW).R)
+	assert.Equal(t, uint16(0), c.(RGBW).W)
+
+	// colors of other types have no white
+	assert.Equal(t, RGBW{R: 0xffff, G: 0xffff, B: 0xffff}, ToRGBW(color.White))
+
+	for s, msg := range map[string]string{
+		"":                  `invalid color "": empty color`,
+		"#12345":            `invalid color "#12345": hex colors must have 3, 4, 6 or 8 digits`,
+		"#ff00zz":           `invalid color "#ff00zz": invalid hex digit 'z'`,
+		"rgb(1, 2)":         `invalid color "rgb(1, 2)": rgb() takes 3 or 4 components, got 2`,
+		"rgb(1, 2, 300)":    `invalid color "rgb(1, 2, 300)": blue must be between 0 and 255, got 300`,
+		"hsl(0, 120%, 50%)": `invalid color "hsl(0, 120%, 50%)": saturation must be between 0% and 100%, got 120%`,
+		"cmyk(0, 0, 0, 0)":  `invalid color "cmyk(0, 0, 0, 0)": unknown color function cmyk()`,
+		"reddish":           `invalid color "reddish": unknown color name`,
+		"500K":              `invalid color "500K": color temperature must be between 1000K and 25000K, got 500K`,
+	} {
+		_, err := FromString(s)
+		assert.EqualError(t, err, msg)
+	}
+}
+
+func TestFormatColor(t *testing.T) {
+	purple := RGBW{R: 0x6666, G: 0x3333, B: 0x9999}
+
+	for form, s := range map[Form]string{
+		Hex:      "#66339900",
+		HexRGB:   "#663399",
+		ShortHex: "#639",
+		RGB:      "rgb(102, 51, 153)",
+		RGBA:     "rgba(102, 51, 153, 1)",
+		HSL:      "hsl(270, 50%, 40%)",
+		HSV:      "hsv(270, 66.67%, 60%)",
+		Name:     "rebeccapurple",
+		Kelvin:   "#663399",
+	} {
+		assert.Equal(t, s, Format(purple, form), form)
+
+		// every form parses back to the same color
+		c, err := FromString(s)
+		assert.Nil(t, err)
+		assert.Equal(t, ToString(purple), ToString(c), form)
+	}
+
+	warm, err := FromString("2700K")
+	assert.Nil(t, err)
+	assert.Equal(t, "2700K", Format(warm, Kelvin))
+
+	// white can only be written in hex
+	assert.Equal(t, "#ff000080", Format(RGBW{R: 0xffff, W: 0x8080}, RGB))
+}
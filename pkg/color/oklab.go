@@ -0,0 +1,35 @@
+package color
+
+import "math"
+
+// LinearToOklab converts linear sRGB components to the Oklab perceptual color
+// space.
+func LinearToOklab(r, g, b float64) (l, a, bb float64) {
+	lms0 := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	lms1 := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	lms2 := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	l = 0.2104542553*lms0 + 0.7936177850*lms1 - 0.0040720468*lms2
+	a = 1.9779984951*lms0 - 2.4285922050*lms1 + 0.4505937099*lms2
+	bb = 0.0259040371*lms0 + 0.7827717662*lms1 - 0.8086757660*lms2
+
+	return l, a, bb
+}
+
+// OklabToLinear converts a color in the Oklab perceptual color space to linear
+// sRGB components. The components are not clamped.
+func OklabToLinear(l, a, bb float64) (r, g, b float64) {
+	lms0 := l + 0.3963377774*a + 0.2158037573*bb
+	lms1 := l - 0.1055613458*a - 0.0638541728*bb
+	lms2 := l - 0.0894841775*a - 1.2914855480*bb
+
+	lms0 = lms0 * lms0 * lms0
+	lms1 = lms1 * lms1 * lms1
+	lms2 = lms2 * lms2 * lms2
+
+	r = 4.0767416621*lms0 - 3.3077115913*lms1 + 0.2309699292*lms2
+	g = -1.2684380046*lms0 + 2.6097574011*lms1 - 0.3413193965*lms2
+	b = -0.0041960863*lms0 - 0.7034186147*lms1 + 1.7076147010*lms2
+
+	return r, g, b
+}
@@ -0,0 +1,154 @@
+package color
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	minTemperature = 1000
+	maxTemperature = 25000
+
+	// splineTemperature is the lowest temperature the splines of the
+	// Planckian locus are defined for.
+	splineTemperature = 1667
+)
+
+// TemperatureLinear returns the color of a black-body radiator at the given
+// temperature in Kelvin as linear RGB components, scaled so that the largest
+// component is 1. Temperatures are clamped to the range 1000-25000K.
+func TemperatureLinear(kelvin float64) (r, g, b float64) {
+	kelvin = math.Max(minTemperature, math.Min(maxTemperature, kelvin))
+
+	var x, y float64
+	if kelvin < splineTemperature {
+		x, y = lowTemperature(kelvin)
+	} else {
+		x, y = planckianLocus(kelvin)
+	}
+
+	// xyY with Y = 1 to XYZ
+	X := x / y
+	Y := 1.0
+	Z := (1 - x - y) / y
+
+	// XYZ to linear sRGB (D65)
+	r = 3.2404542*X - 1.5371385*Y - 0.4985314*Z
+	g = -0.9692660*X + 1.8760108*Y + 0.0415560*Z
+	b = 0.0556434*X - 0.2040259*Y + 1.0572252*Z
+
+	r, g, b = math.Max(r, 0), math.Max(g, 0), math.Max(b, 0)
+
+	max := math.Max(r, math.Max(g, b))
+	if max == 0 {
+		return 0, 0, 0
+	}
+
+	return r / max, g / max, b / max
+}
+
+// Temperature returns the sRGB color of a black-body radiator at the given
+// temperature in Kelvin, at full brightness.
+func Temperature(kelvin float64) color.RGBA64 {
+	r, g, b := TemperatureLinear(kelvin)
+
+	return color.RGBA64{
+		R: uint16(math.Round(LinearToSRGB(r) * 0xffff)),
+		G: uint16(math.Round(LinearToSRGB(g) * 0xffff)),
+		B: uint16(math.Round(LinearToSRGB(b) * 0xffff)),
+		A: 0xffff,
+	}
+}
+
+// planckianLocus approximates the CIE 1931 chromaticity of a black-body
+// radiator using the cubic splines by Kim et al.
+func planckianLocus(kelvin float64) (x, y float64) {
+	t := math.Max(splineTemperature, math.Min(maxTemperature, kelvin))
+
+	if t < 4000 {
+		x = -0.2661239e9/(t*t*t) - 0.2343589e6/(t*t) + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/(t*t*t) + 2.1070379e6/(t*t) + 0.2226347e3/t + 0.240390
+	}
+
+	switch {
+	case t < 2222:
+		y = -1.1063814*x*x*x - 1.34811020*x*x + 2.18555832*x - 0.20219683
+	case t < 4000:
+		y = -0.9549476*x*x*x - 1.37418593*x*x + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x*x*x - 5.87338670*x*x + 3.75112997*x - 0.37001483
+	}
+
+	return x, y
+}
+
+// lowTemperature returns the chromaticity of temperatures below the range of
+// the splines. The integrated black body doesn't quite meet the splines, so
+// their difference at splineTemperature is faded in towards it, which keeps
+// the color from jumping there.
+func lowTemperature(kelvin float64) (x, y float64) {
+	x, y = blackBody(kelvin)
+
+	bx, by := blackBody(splineTemperature)
+	sx, sy := planckianLocus(splineTemperature)
+
+	w := (kelvin - minTemperature) / (splineTemperature - minTemperature)
+
+	return x + (sx-bx)*w, y + (sy-by)*w
+}
+
+// blackBody computes the CIE 1931 chromaticity of a black-body radiator by
+// integrating Planck's law over the visible spectrum, with the analytic fit of
+// the color matching functions by Wyman et al.
+func blackBody(kelvin float64) (x, y float64) {
+	// second radiation constant in m K
+	const c2 = 1.4387769e-2
+
+	var X, Y, Z float64
+
+	for nm := 380.0; nm <= 780; nm += 5 {
+		l := nm * 1e-9
+		p := 1 / (math.Pow(l, 5) * (math.Exp(c2/(l*kelvin)) - 1))
+
+		X += p * (1.056*lobe(nm, 599.8, 37.9, 31.0) + 0.362*lobe(nm, 442.0, 16.0, 26.7) - 0.065*lobe(nm, 501.1, 20.4, 26.2))
+		Y += p * (0.821*lobe(nm, 568.8, 46.9, 40.5) + 0.286*lobe(nm, 530.9, 16.3, 31.1))
+		Z += p * (1.217*lobe(nm, 437.0, 11.8, 36.0) + 0.681*lobe(nm, 459.0, 26.0, 13.8))
+	}
+
+	sum := X + Y + Z
+
+	return X / sum, Y / sum
+}
+
+// lobe is a piecewise Gaussian with different widths below and above its mean.
+func lobe(x, mean, below, above float64) float64 {
+	s := below
+	if x >= mean {
+		s = above
+	}
+
+	t := (x - mean) / s
+
+	return math.Exp(-t * t / 2)
+}
+
+// SRGBToLinear converts an sRGB encoded component in the range [0, 1] to
+// linear light.
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB converts a linear light component in the range [0, 1] to its
+// sRGB encoding.
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
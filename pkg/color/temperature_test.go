@@ -0,0 +1,26 @@
+package color
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemperatureContinuous(t *testing.T) {
+	// the curve doesn't jump where the splines take over
+	r0, g0, b0 := TemperatureLinear(splineTemperature - 0.01)
+	r1, g1, b1 := TemperatureLinear(splineTemperature)
+
+	assert.InDelta(t, r1, r0, 1e-4)
+	assert.InDelta(t, g1, g0, 1e-4)
+	assert.InDelta(t, b1, b0, 1e-4)
+
+	// and keeps warming below it
+	prev := math.Inf(1)
+	for k := float64(splineTemperature); k >= minTemperature; k -= 50 {
+		_, g, _ := TemperatureLinear(k)
+		assert.Less(t, g, prev, k)
+		prev = g
+	}
+}
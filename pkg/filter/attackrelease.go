@@ -0,0 +1,61 @@
+package filter
+
+import (
+	"math"
+	"time"
+)
+
+type attackRelease struct {
+	attack  float64
+	release float64
+	prev    []float64
+	last    time.Time
+}
+
+// NewAttackRelease creates a filter that follows rising values with the
+// attack time constant and falling values with the release one. A short attack
+// and a long release make audio visualizations snap to beats and fade out
+// smoothly.
+func NewAttackRelease(attack, release time.Duration) Filter {
+	return &attackRelease{
+		attack:  attack.Seconds(),
+		release: release.Seconds(),
+	}
+}
+
+func (f *attackRelease) Apply(values []float64, t time.Time) {
+	if len(values) != len(f.prev) {
+		f.prev = append(f.prev[:0], values...)
+		f.last = t
+		return
+	}
+
+	dt := elapsed(f.last, t)
+	f.last = t
+
+	attack, release := smoothing(dt, f.attack), smoothing(dt, f.release)
+
+	for i, v := range values {
+		a := release
+		if v > f.prev[i] {
+			a = attack
+		}
+
+		f.prev[i] += (v - f.prev[i]) * a
+		values[i] = f.prev[i]
+	}
+}
+
+func (f *attackRelease) Reset() {
+	f.prev = f.prev[:0]
+}
+
+// smoothing returns the weight of a new value for a low-pass filter with time
+// constant tau, dt seconds after the previous one.
+func smoothing(dt, tau float64) float64 {
+	if tau <= 0 {
+		return 1
+	}
+
+	return 1 - math.Exp(-dt/tau)
+}
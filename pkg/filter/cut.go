@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"math"
+	"time"
+)
+
+// cut resets a filter on frames that differ a lot from the previous one, so
+// that hard cuts in video show up at once instead of being smoothed over.
+type cut struct {
+	filter    Filter
+	threshold float64
+	prev      []float64
+}
+
+// NewCut wraps a filter so that it is reset whenever the values of a frame
+// differ from those of the previous frame by more than threshold on average.
+func NewCut(f Filter, threshold float64) Filter {
+	return &cut{
+		filter:    f,
+		threshold: threshold,
+	}
+}
+
+func (f *cut) Apply(values []float64, t time.Time) {
+	if len(values) == len(f.prev) && len(values) > 0 {
+		var d float64
+		for i, v := range values {
+			d += math.Abs(v - f.prev[i])
+		}
+
+		if d/float64(len(values)) > f.threshold {
+			f.filter.Reset()
+		}
+	}
+
+	f.prev = append(f.prev[:0], values...)
+
+	f.filter.Apply(values, t)
+}
+
+func (f *cut) Reset() {
+	f.prev = f.prev[:0]
+	f.filter.Reset()
+}
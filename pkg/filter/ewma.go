@@ -0,0 +1,32 @@
+package filter
+
+import "time"
+
+type ewma struct {
+	constant float64
+	prev     []float64
+}
+
+// NewEWMA creates an exponentially weighted moving average whose weights decay
+// over a window of frames, regardless of the time between them.
+func NewEWMA(window int) Filter {
+	return &ewma{
+		constant: 2 / (1 + float64(window)),
+	}
+}
+
+func (f *ewma) Apply(values []float64, _ time.Time) {
+	if len(values) != len(f.prev) {
+		f.prev = append(f.prev[:0], values...)
+		return
+	}
+
+	for i, v := range values {
+		f.prev[i] += (v - f.prev[i]) * f.constant
+		values[i] = f.prev[i]
+	}
+}
+
+func (f *ewma) Reset() {
+	f.prev = f.prev[:0]
+}
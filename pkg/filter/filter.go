@@ -0,0 +1,226 @@
+package filter
+
+import (
+	"errors"
+	"image/color"
+	"time"
+)
+
+// Filter smooths successive frames of pixel values. The values of a frame are
+// the channels of its pixels, normalized to the range 0 to 1. Filters keep
+// state between frames and are not safe for concurrent use.
+type Filter interface {
+	// Apply filters the values of the frame captured at time t in place. A
+	// frame with a different amount of values than the previous one resets
+	// the filter.
+	Apply(values []float64, t time.Time)
+	// Reset discards the state of the filter, so that the next frame is
+	// passed through as is.
+	Reset()
+}
+
+// Type selects a filter implementation.
+type Type string
+
+const (
+	// None passes frames through as is.
+	None Type = "none"
+	// EWMA is an exponentially weighted moving average over a window of
+	// frames.
+	EWMA Type = "ewma"
+	// AttackRelease follows rising values quickly and falling values slowly,
+	// like the meter of a mixing desk.
+	AttackRelease Type = "attackRelease"
+	// OneEuro is a low-pass filter whose cutoff rises with the speed of the
+	// values, so it smooths still content and stays responsive on motion.
+	OneEuro Type = "oneEuro"
+	// Median outputs the median of a window of frames, which drops outliers.
+	Median Type = "median"
+)
+
+// Spec describes a filter. Only the fields that apply to its type are used;
+// zero values select the defaults.
+type Spec struct {
+	Type Type
+
+	// Window is the amount of frames averaged by EWMA, or the amount of
+	// frames the median is taken from.
+	Window int
+
+	// Attack and Release are the time constants AttackRelease takes to
+	// follow rising and falling values.
+	Attack  time.Duration
+	Release time.Duration
+
+	// MinCutoff is the cutoff frequency of OneEuro in Hz when the values are
+	// still, and Beta how quickly it rises with their speed.
+	// DerivativeCutoff is the cutoff frequency the speed is smoothed with.
+	MinCutoff        float64
+	Beta             float64
+	DerivativeCutoff float64
+
+	// Cut resets the filter on frames whose values differ from the previous
+	// frame by more than it on average, in the range 0 to 1. Hard cuts then
+	// show up at once while small changes are still smoothed. Zero disables
+	// it.
+	Cut float64
+}
+
+const (
+	defaultWindow           = 5
+	defaultAttack           = 10 * time.Millisecond
+	defaultRelease          = 300 * time.Millisecond
+	defaultMinCutoff        = 1
+	defaultBeta             = 0.5
+	defaultDerivativeCutoff = 1
+)
+
+// New creates a filter from its spec.
+func New(spec Spec) (Filter, error) {
+	if spec.Window < 0 || spec.Window > 1000 {
+		return nil, errors.New("filter window must be at most 1000 frames, or 0 for the default")
+	}
+
+	if spec.Attack < 0 || spec.Release < 0 {
+		return nil, errors.New("filter attack and release must not be negative")
+	}
+
+	if spec.MinCutoff < 0 || spec.Beta < 0 || spec.DerivativeCutoff < 0 {
+		return nil, errors.New("filter cutoffs and beta must not be negative")
+	}
+
+	if spec.Cut < 0 || spec.Cut > 1 {
+		return nil, errors.New("filter cut threshold must be between 0 and 1")
+	}
+
+	f, err := newFilter(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.(none); ok || spec.Cut == 0 {
+		return f, nil
+	}
+
+	return NewCut(f, spec.Cut), nil
+}
+
+func newFilter(spec Spec) (Filter, error) {
+	window := spec.Window
+	if window == 0 {
+		window = defaultWindow
+	}
+
+	switch spec.Type {
+	case "", None:
+		return none{}, nil
+	case EWMA:
+		return NewEWMA(window), nil
+	case AttackRelease:
+		attack, release := spec.Attack, spec.Release
+		if attack == 0 {
+			attack = defaultAttack
+		}
+		if release == 0 {
+			release = defaultRelease
+		}
+
+		return NewAttackRelease(attack, release), nil
+	case OneEuro:
+		minCutoff, beta, dCutoff := spec.MinCutoff, spec.Beta, spec.DerivativeCutoff
+		if minCutoff == 0 {
+			minCutoff = defaultMinCutoff
+		}
+		if beta == 0 {
+			beta = defaultBeta
+		}
+		if dCutoff == 0 {
+			dCutoff = defaultDerivativeCutoff
+		}
+
+		return NewOneEuro(minCutoff, beta, dCutoff), nil
+	case Median:
+		return NewMedian(window), nil
+	default:
+		return nil, errors.New("invalid filter type")
+	}
+}
+
+type none struct{}
+
+func (none) Apply([]float64, time.Time) {}
+
+func (none) Reset() {}
+
+// Colors applies a filter to frames of colors. The four components of each
+// color are filtered separately.
+type Colors struct {
+	filter Filter
+	values []float64
+}
+
+// NewColors wraps a filter so that it filters colors.
+func NewColors(f Filter) *Colors {
+	return &Colors{filter: f}
+}
+
+// Apply filters the colors of the frame captured at time t in place. Filtered
+// colors are replaced with color.RGBA64 values.
+func (c *Colors) Apply(colors []color.Color, t time.Time) {
+	if _, ok := c.filter.(none); ok {
+		return
+	}
+
+	n := len(colors) * 4
+	if cap(c.values) < n {
+		c.values = make([]float64, n)
+	}
+	c.values = c.values[:n]
+
+	for i, clr := range colors {
+		r, g, b, a := clr.RGBA()
+		c.values[i*4] = float64(r) / 0xffff
+		c.values[i*4+1] = float64(g) / 0xffff
+		c.values[i*4+2] = float64(b) / 0xffff
+		c.values[i*4+3] = float64(a) / 0xffff
+	}
+
+	c.filter.Apply(c.values, t)
+
+	for i := range colors {
+		colors[i] = color.RGBA64{
+			R: encode(c.values[i*4]),
+			G: encode(c.values[i*4+1]),
+			B: encode(c.values[i*4+2]),
+			A: encode(c.values[i*4+3]),
+		}
+	}
+}
+
+// Reset discards the state of the filter.
+func (c *Colors) Reset() {
+	c.filter.Reset()
+}
+
+func encode(v float64) uint16 {
+	if v <= 0 {
+		return 0
+	}
+
+	if v >= 1 {
+		return 0xffff
+	}
+
+	return uint16(v*0xffff + 0.5)
+}
+
+// elapsed returns the seconds between two frames. Frames that arrive out of
+// order count as simultaneous.
+func elapsed(prev, t time.Time) float64 {
+	dt := t.Sub(prev).Seconds()
+	if dt < 0 {
+		return 0
+	}
+
+	return dt
+}
@@ -0,0 +1,153 @@
+package filter
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterEWMA(t *testing.T) {
+	f, err := New(Spec{Type: EWMA, Window: 3})
+	assert.Nil(t, err)
+
+	now := time.Now()
+
+	// the first frame passes through
+	v := []float64{0, 1}
+	f.Apply(v, now)
+	assert.Equal(t, []float64{0, 1}, v)
+
+	v = []float64{1, 0}
+	f.Apply(v, now)
+	assert.Equal(t, []float64{0.5, 0.5}, v)
+
+	// a frame of another size resets the filter
+	v = []float64{1}
+	f.Apply(v, now)
+	assert.Equal(t, []float64{1}, v)
+}
+
+func TestFilterAttackRelease(t *testing.T) {
+	f, err := New(Spec{Type: AttackRelease, Attack: 10 * time.Millisecond, Release: time.Second})
+	assert.Nil(t, err)
+
+	now := time.Now()
+	f.Apply([]float64{0, 1}, now)
+
+	v := []float64{1, 0}
+	f.Apply(v, now.Add(50*time.Millisecond))
+
+	// rising values are followed quickly, falling ones slowly
+	assert.Greater(t, v[0], 0.99)
+	assert.Greater(t, v[1], 0.9)
+
+	// frames out of order don't move the values
+	prev := append([]float64{}, v...)
+	f.Apply(v, now)
+	assert.Equal(t, prev, v)
+}
+
+func TestFilterOneEuro(t *testing.T) {
+	f, err := New(Spec{Type: OneEuro, MinCutoff: 1, Beta: 1})
+	assert.Nil(t, err)
+
+	now := time.Now()
+	frame := 16 * time.Millisecond
+
+	f.Apply([]float64{0.5}, now)
+
+	// jitter around a still value is smoothed out
+	for i := 1; i <= 60; i++ {
+		v := []float64{0.5 + 0.02*float64(i%2*2-1)}
+		f.Apply(v, now.Add(time.Duration(i)*frame))
+		assert.InDelta(t, 0.5, v[0], 0.01)
+	}
+
+	// a fast motion raises the cutoff, so the filter catches up quickly
+	slow, err := New(Spec{Type: OneEuro, MinCutoff: 1, Beta: 0.001})
+	assert.Nil(t, err)
+	slow.Apply([]float64{0}, now)
+	f.Reset()
+	f.Apply([]float64{0}, now)
+
+	var fast, lagging []float64
+	for i := 1; i <= 5; i++ {
+		fast = []float64{float64(i) * 0.2}
+		f.Apply(fast, now.Add(time.Duration(i)*frame))
+
+		lagging = []float64{float64(i) * 0.2}
+		slow.Apply(lagging, now.Add(time.Duration(i)*frame))
+	}
+
+	assert.Greater(t, fast[0], lagging[0])
+	assert.Greater(t, fast[0], 0.7)
+}
+
+func TestFilterMedian(t *testing.T) {
+	f, err := New(Spec{Type: Median, Window: 3})
+	assert.Nil(t, err)
+
+	now := time.Now()
+
+	var out []float64
+	for _, v := range []float64{0.2, 0.2, 1, 0.2, 0.6, 0.6} {
+		frame := []float64{v}
+		f.Apply(frame, now)
+		out = append(out, frame[0])
+	}
+
+	// the single frame flash is dropped, the step is kept
+	assert.Equal(t, []float64{0.2, 0.2, 0.2, 0.2, 0.6, 0.6}, out)
+}
+
+func TestFilterCut(t *testing.T) {
+	f, err := New(Spec{Type: EWMA, Window: 3, Cut: 0.3})
+	assert.Nil(t, err)
+
+	now := time.Now()
+	f.Apply([]float64{0, 0}, now)
+
+	// small changes are smoothed
+	v := []float64{0.2, 0.2}
+	f.Apply(v, now)
+	assert.InDeltaSlice(t, []float64{0.1, 0.1}, v, 1e-9)
+
+	// a cut snaps to the new frame
+	v = []float64{1, 0.8}
+	f.Apply(v, now)
+	assert.Equal(t, []float64{1, 0.8}, v)
+
+	v = []float64{0.8, 0.8}
+	f.Apply(v, now)
+	assert.InDeltaSlice(t, []float64{0.9, 0.8}, v, 1e-9)
+
+	_, err = New(Spec{Type: EWMA, Cut: 2})
+	assert.NotNil(t, err)
+}
+
+func TestFilterColors(t *testing.T) {
+	f, err := New(Spec{Type: EWMA, Window: 3})
+	assert.Nil(t, err)
+
+	c := NewColors(f)
+	now := time.Now()
+
+	colors := []color.Color{color.RGBA{R: 0xff, A: 0xff}}
+	c.Apply(colors, now)
+	assert.Equal(t, color.RGBA64{R: 0xffff, A: 0xffff}, colors[0])
+
+	colors = []color.Color{color.RGBA{B: 0xff, A: 0xff}}
+	c.Apply(colors, now)
+	assert.Equal(t, color.RGBA64{R: 0x8000, B: 0x8000, A: 0xffff}, colors[0])
+
+	for _, spec := range []Spec{
+		{Type: "kalman"},
+		{Type: Median, Window: -1},
+		{Type: AttackRelease, Release: -time.Second},
+	} {
+		_, err := New(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
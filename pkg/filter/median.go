@@ -0,0 +1,67 @@
+package filter
+
+import "time"
+
+type median struct {
+	window int
+	// history holds the last frames in a ring, frame after frame.
+	history []float64
+	frames  int
+	next    int
+	size    int
+	sorted  []float64
+}
+
+// NewMedian creates a filter that outputs the median of each value over the
+// last window frames, which removes single frame outliers like flashes
+// without blurring steps.
+func NewMedian(window int) Filter {
+	return &median{
+		window: window,
+		sorted: make([]float64, 0, window),
+	}
+}
+
+func (f *median) Apply(values []float64, _ time.Time) {
+	if len(values) != f.size {
+		f.size = len(values)
+		f.history = make([]float64, f.size*f.window)
+		f.frames = 0
+		f.next = 0
+	}
+
+	copy(f.history[f.next*f.size:], values)
+	f.next = (f.next + 1) % f.window
+	if f.frames < f.window {
+		f.frames++
+	}
+
+	for i := range values {
+		s := f.sorted[:0]
+
+		for j := 0; j < f.frames; j++ {
+			v := f.history[j*f.size+i]
+
+			// insertion sort, as windows are small
+			k := len(s)
+			s = append(s, v)
+			for k > 0 && s[k-1] > v {
+				s[k] = s[k-1]
+				k--
+			}
+			s[k] = v
+		}
+
+		n := len(s)
+		if n%2 == 1 {
+			values[i] = s[n/2]
+		} else {
+			values[i] = (s[n/2-1] + s[n/2]) / 2
+		}
+	}
+}
+
+func (f *median) Reset() {
+	f.size = 0
+	f.history = nil
+}
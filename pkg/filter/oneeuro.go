@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"math"
+	"time"
+)
+
+// oneEuro implements the 1€ filter by Casiez, Roussel and Vogel, applied to
+// each value separately.
+type oneEuro struct {
+	minCutoff float64
+	beta      float64
+	dCutoff   float64
+
+	prev  []float64
+	deriv []float64
+	last  time.Time
+}
+
+// NewOneEuro creates a 1€ filter. Its cutoff frequency is minCutoff Hz while
+// values are still and rises by beta Hz per unit of speed, with the speed
+// itself smoothed at dCutoff Hz. Lowering minCutoff reduces jitter, raising
+// beta reduces lag on motion.
+func NewOneEuro(minCutoff, beta, dCutoff float64) Filter {
+	return &oneEuro{
+		minCutoff: minCutoff,
+		beta:      beta,
+		dCutoff:   dCutoff,
+	}
+}
+
+func (f *oneEuro) Apply(values []float64, t time.Time) {
+	if len(values) != len(f.prev) {
+		f.prev = append(f.prev[:0], values...)
+		f.deriv = append(f.deriv[:0], make([]float64, len(values))...)
+		f.last = t
+		return
+	}
+
+	dt := elapsed(f.last, t)
+	f.last = t
+
+	if dt == 0 {
+		copy(values, f.prev)
+		return
+	}
+
+	ad := cutoffSmoothing(dt, f.dCutoff)
+
+	for i, v := range values {
+		d := (v - f.prev[i]) / dt
+		f.deriv[i] += (d - f.deriv[i]) * ad
+
+		cutoff := f.minCutoff + f.beta*math.Abs(f.deriv[i])
+
+		f.prev[i] += (v - f.prev[i]) * cutoffSmoothing(dt, cutoff)
+		values[i] = f.prev[i]
+	}
+}
+
+func (f *oneEuro) Reset() {
+	f.prev = f.prev[:0]
+	f.deriv = f.deriv[:0]
+}
+
+// cutoffSmoothing returns the weight of a new value for a low-pass filter with
+// the given cutoff frequency, dt seconds after the previous one.
+func cutoffSmoothing(dt, cutoff float64) float64 {
+	tau := 1 / (2 * math.Pi * cutoff)
+	return 1 / (1 + tau/dt)
+}
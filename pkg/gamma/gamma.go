@@ -0,0 +1,79 @@
+package gamma
+
+import (
+	"math"
+)
+
+// Curve maps a perceptual input level to a linear output level. Both levels
+// are in the range [0, 1].
+type Curve func(v float64) float64
+
+// Linear passes input levels through unchanged.
+func Linear() Curve {
+	return func(v float64) float64 {
+		return v
+	}
+}
+
+// Power is a classic gamma curve with the given exponent.
+func Power(exp float64) Curve {
+	return func(v float64) float64 {
+		return math.Pow(v, exp)
+	}
+}
+
+// CIE maps the input level as CIE 1931 lightness (L*) to relative luminance,
+// which gives perceptually uniform steps in brightness.
+func CIE() Curve {
+	return func(v float64) float64 {
+		l := v * 100
+
+		if l <= 8 {
+			return l / 903.3
+		}
+
+		return math.Pow((l+16)/116, 3)
+	}
+}
+
+// tableBits is the amount of most significant input bits the tables are
+// indexed with. The remaining bits are used to interpolate between entries.
+const tableBits = 12
+
+// Table is a precomputed curve for 16-bit levels.
+type Table [1<<tableBits + 1]uint16
+
+// NewTable samples the curve and scales the result by brightness, which is in
+// the range [0, 1]. Because brightness is applied after the curve, it scales
+// the linear output rather than the perceptual input.
+func NewTable(c Curve, brightness float64) *Table {
+	var t Table
+
+	brightness = math.Max(0, math.Min(1, brightness))
+
+	for i := range t {
+		v := c(float64(i)/(1<<tableBits)) * brightness
+		v = math.Max(0, math.Min(1, v))
+
+		t[i] = uint16(math.Round(v * 0xffff))
+	}
+
+	return &t
+}
+
+// Apply maps a 16-bit input level to its output level.
+func (t *Table) Apply(v uint16) uint16 {
+	// position of the level in the table, with 16 fractional bits
+	p := uint64(v) << (tableBits + 16) / 0xffff
+
+	i := p >> 16
+	f := p & 0xffff
+
+	if i >= 1<<tableBits {
+		return t[1<<tableBits]
+	}
+
+	a, b := uint64(t[i]), uint64(t[i+1])
+
+	return uint16((a*(0x10000-f) + b*f + 0x8000) >> 16)
+}
@@ -3,53 +3,290 @@ package gradient
 import (
 	"errors"
 	"image/color"
+	"math"
+	"sort"
 
 	"github.com/lucasb-eyer/go-colorful"
+
+	clr "ledctl3/pkg/color"
+)
+
+// Space is the color space colors are interpolated in.
+type Space string
+
+const (
+	LinearRGB Space = "linear"
+	Luv       Space = "luv"
+	HCL       Space = "hcl"
+	Oklab     Space = "oklab"
+)
+
+// Easing shapes the interpolation from a stop to the next one.
+type Easing string
+
+const (
+	Linear    Easing = "linear"
+	EaseIn    Easing = "easeIn"
+	EaseOut   Easing = "easeOut"
+	EaseInOut Easing = "easeInOut"
+	// Hold keeps the color of the stop until the next one.
+	Hold Easing = "hold"
 )
 
-// Gradient represents a color gradient
-type Gradient []Step
+var easings = map[Easing]func(t float64) float64{
+	"":     func(t float64) float64 { return t },
+	Linear: func(t float64) float64 { return t },
+	EaseIn: func(t float64) float64 { return t * t * t },
+	EaseOut: func(t float64) float64 {
+		t = 1 - t
+		return 1 - t*t*t
+	},
+	EaseInOut: func(t float64) float64 {
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+
+		t = -2*t + 2
+		return 1 - t*t*t/2
+	},
+	Hold: func(t float64) float64 { return 0 },
+}
 
-// Step is a point in the gradient that has a specific color
-type Step struct {
+// Stop is a color at a position of the gradient between 0 and 1. Easing shapes
+// the interpolation towards the next stop.
+type Stop struct {
 	Color    color.Color
 	Position float64
+	Easing   Easing
 }
 
-// GetInterpolatedColor returns the color between the two steps that are
-// around t.
-func (gt Gradient) GetInterpolatedColor(t float64) color.Color {
-	for i := 0; i < len(gt)-1; i++ {
-		c1 := gt[i]
-		c2 := gt[i+1]
-		c1c, _ := colorful.MakeColor(c1.Color)
-		c2c, _ := colorful.MakeColor(c2.Color)
-		if c1.Position <= t && t <= c2.Position {
-			// We are in between c1 and c2. Go blend them!
-			t := (t - c1.Position) / (c2.Position - c1.Position)
+// Gradient interpolates colors between stops. The colors of the stops are
+// converted to the interpolation space once, when the gradient is created. The
+// white channel of RGBW colors is interpolated linearly.
+type Gradient struct {
+	space  Space
+	cyclic bool
+	stops  []stop
+}
+
+type stop struct {
+	position float64
+	ease     func(t float64) float64
+	// c holds the coordinates of the color in the interpolation space, and
+	// the fourth component.
+	c [4]float64
+}
 
-			return c1c.BlendLuv(c2c, t).Clamped()
+type Option func(g *Gradient) error
+
+// WithSpace sets the color space colors are interpolated in. It defaults to
+// Luv.
+func WithSpace(space Space) Option {
+	return func(g *Gradient) error {
+		switch space {
+		case "":
+			g.space = Luv
+		case LinearRGB, Luv, HCL, Oklab:
+			g.space = space
+		default:
+			return errors.New("invalid gradient color space")
 		}
+
+		return nil
 	}
+}
 
-	// Nothing found? Means we're at (or past) the last gradient step.
-	return gt[len(gt)-1].Color
+// WithCyclic makes the gradient wrap around from its last stop to its first, so
+// that it can be scrolled seamlessly.
+func WithCyclic(cyclic bool) Option {
+	return func(g *Gradient) error {
+		g.cyclic = cyclic
+		return nil
+	}
 }
 
-// New parses the colors into a linearly-interpolated gradient
-func New(colors ...color.Color) (Gradient, error) {
-	if len(colors) < 2 {
-		return Gradient{}, errors.New("minimum two colors required")
+// New creates a gradient through the stops, which must be sorted by position.
+func New(stops []Stop, opts ...Option) (*Gradient, error) {
+	if len(stops) < 2 {
+		return nil, errors.New("minimum two gradient stops required")
 	}
 
-	g := Gradient{}
+	g := &Gradient{space: Luv}
 
-	for i, clr := range colors {
-		g = append(g, Step{
-			Color:    clr,
-			Position: float64(i) / float64(len(colors)-1),
-		})
+	for _, opt := range opts {
+		err := opt(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.stops = make([]stop, len(stops))
+
+	for i, s := range stops {
+		if s.Position < 0 || s.Position > 1 {
+			return nil, errors.New("gradient stop position must be between 0 and 1")
+		}
+
+		if i > 0 && s.Position < stops[i-1].Position {
+			return nil, errors.New("gradient stops must be sorted by position")
+		}
+
+		ease, ok := easings[s.Easing]
+		if !ok {
+			return nil, errors.New("invalid gradient easing")
+		}
+
+		g.stops[i] = stop{
+			position: s.Position,
+			ease:     ease,
+			c:        g.encode(s.Color),
+		}
 	}
 
 	return g, nil
 }
+
+// Uniform returns stops that space the colors evenly.
+func Uniform(colors ...color.Color) []Stop {
+	stops := make([]Stop, len(colors))
+
+	for i, c := range colors {
+		stops[i] = Stop{Color: c}
+		if len(colors) > 1 {
+			stops[i].Position = float64(i) / float64(len(colors)-1)
+		}
+	}
+
+	return stops
+}
+
+// At returns the color at position t. Cyclic gradients wrap t around, others
+// clamp it to the range 0 to 1.
+func (g *Gradient) At(t float64) clr.RGBW {
+	if g.cyclic {
+		t -= math.Floor(t)
+	} else {
+		t = math.Max(0, math.Min(1, t))
+	}
+
+	stops := g.stops
+	n := len(stops)
+
+	// the first stop after t
+	i := sort.Search(n, func(i int) bool {
+		return stops[i].position > t
+	})
+
+	var from, to stop
+	var start, end float64
+
+	switch {
+	case i > 0 && i < n:
+		from, to = stops[i-1], stops[i]
+		start, end = from.position, to.position
+	case !g.cyclic && i == 0:
+		return g.decode(stops[0].c)
+	case !g.cyclic:
+		return g.decode(stops[n-1].c)
+	case i == 0:
+		// before the first stop, coming from the last one
+		from, to = stops[n-1], stops[0]
+		start, end = from.position-1, to.position
+	default:
+		// after the last stop, going to the first one
+		from, to = stops[n-1], stops[0]
+		start, end = from.position, to.position+1
+	}
+
+	var p float64
+	if end > start {
+		p = from.ease((t - start) / (end - start))
+	}
+
+	return g.decode(g.mix(from.c, to.c, p))
+}
+
+// Sample fills dst with colors sampled evenly along the gradient and shifted by
+// offset. Cyclic gradients are sampled as a loop, others from start to end.
+func (g *Gradient) Sample(dst []clr.RGBW, offset float64) {
+	n := len(dst)
+
+	step := 0.0
+	switch {
+	case g.cyclic:
+		step = 1 / float64(n)
+	case n > 1:
+		step = 1 / float64(n-1)
+	}
+
+	for i := range dst {
+		dst[i] = g.At(float64(i)*step + offset)
+	}
+}
+
+func (g *Gradient) mix(a, b [4]float64, p float64) [4]float64 {
+	var c [4]float64
+
+	for i := range c {
+		c[i] = a[i] + (b[i]-a[i])*p
+	}
+
+	if g.space == HCL {
+		// take the shorter way around the hue circle
+		h1, h2 := a[0], b[0]
+		if d := h2 - h1; d > 180 {
+			h1 += 360
+		} else if d < -180 {
+			h2 += 360
+		}
+
+		c[0] = math.Mod(h1+(h2-h1)*p, 360)
+	}
+
+	return c
+}
+
+// encode converts a color to coordinates in the interpolation space.
+func (g *Gradient) encode(c color.Color) [4]float64 {
+	rgbw := clr.ToRGBW(c)
+
+	cf := colorful.Color{R: float64(rgbw.R) / 0xffff, G: float64(rgbw.G) / 0xffff, B: float64(rgbw.B) / 0xffff}
+	v := [4]float64{3: float64(rgbw.W) / 0xffff}
+
+	switch g.space {
+	case LinearRGB:
+		v[0], v[1], v[2] = cf.LinearRgb()
+	case Luv:
+		v[0], v[1], v[2] = cf.Luv()
+	case HCL:
+		v[0], v[1], v[2] = cf.Hcl()
+	case Oklab:
+		v[0], v[1], v[2] = clr.LinearToOklab(cf.LinearRgb())
+	}
+
+	return v
+}
+
+// decode converts coordinates in the interpolation space to a color.
+func (g *Gradient) decode(v [4]float64) clr.RGBW {
+	var cf colorful.Color
+
+	switch g.space {
+	case LinearRGB:
+		cf = colorful.LinearRgb(v[0], v[1], v[2])
+	case Luv:
+		cf = colorful.Luv(v[0], v[1], v[2])
+	case HCL:
+		cf = colorful.Hcl(v[0], v[1], v[2])
+	case Oklab:
+		cf = colorful.LinearRgb(clr.OklabToLinear(v[0], v[1], v[2]))
+	}
+
+	cf = cf.Clamped()
+
+	encode := func(v float64) uint16 {
+		return uint16(math.Round(math.Max(0, math.Min(1, v)) * 0xffff))
+	}
+
+	return clr.RGBW{R: encode(cf.R), G: encode(cf.G), B: encode(cf.B), W: encode(v[3])}
+}
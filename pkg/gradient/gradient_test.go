@@ -0,0 +1,90 @@
+package gradient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	clr "ledctl3/pkg/color"
+)
+
+func TestGradientSpaces(t *testing.T) {
+	stops := []Stop{
+		{Color: clr.RGBW{R: 0xffff}, Position: 0},
+		{Color: clr.RGBW{B: 0xffff, W: 0xffff}, Position: 1},
+	}
+
+	mid := map[Space]clr.RGBW{}
+
+	for _, space := range []Space{LinearRGB, Luv, HCL, Oklab} {
+		g, err := New(stops, WithSpace(space))
+		assert.Nil(t, err)
+
+		// the stops themselves are exact
+		assert.Equal(t, clr.RGBW{R: 0xffff}, g.At(0), space)
+		assert.Equal(t, clr.RGBW{B: 0xffff, W: 0xffff}, g.At(1), space)
+
+		mid[space] = g.At(0.5)
+
+		// white is interpolated linearly
+		assert.Equal(t, uint16(0x8000), mid[space].W, space)
+	}
+
+	// half the light of each in linear RGB
+	assert.Equal(t, clr.RGBW{R: 0xbc40, B: 0xbc40, W: 0x8000}, mid[LinearRGB])
+
+	assert.NotEqual(t, mid[Luv], mid[Oklab])
+	assert.NotEqual(t, mid[HCL], mid[Oklab])
+
+	_, err := New(stops, WithSpace("cmyk"))
+	assert.NotNil(t, err)
+}
+
+func TestGradientEasing(t *testing.T) {
+	red, blue := clr.RGBW{R: 0xffff}, clr.RGBW{B: 0xffff}
+
+	g, err := New([]Stop{
+		{Color: red, Position: 0, Easing: Hold},
+		{Color: blue, Position: 0.5, Easing: EaseIn},
+		{Color: red, Position: 1},
+	}, WithSpace(LinearRGB))
+	assert.Nil(t, err)
+
+	assert.Equal(t, red, g.At(0.49))
+	assert.Equal(t, blue, g.At(0.5))
+
+	// easing in stays closer to the start
+	c := g.At(0.75)
+	assert.Greater(t, c.B, c.R)
+
+	_, err = New([]Stop{
+		{Color: red, Position: 0, Easing: "bounce"},
+		{Color: blue, Position: 1},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestGradientCyclic(t *testing.T) {
+	red, blue := clr.RGBW{R: 0xffff}, clr.RGBW{B: 0xffff}
+
+	g, err := New([]Stop{
+		{Color: red, Position: 0},
+		{Color: blue, Position: 0.5},
+	}, WithSpace(LinearRGB), WithCyclic(true))
+	assert.Nil(t, err)
+
+	// it wraps around from the last stop to the first
+	assert.Equal(t, g.At(0.25), g.At(0.75))
+	assert.Equal(t, red, g.At(1))
+	assert.Equal(t, g.At(0.1), g.At(-0.9))
+
+	colors := make([]clr.RGBW, 4)
+	g.Sample(colors, 0)
+	assert.Equal(t, red, colors[0])
+	assert.Equal(t, blue, colors[2])
+
+	// sampling shifted by half a cycle swaps the colors
+	g.Sample(colors, 0.5)
+	assert.Equal(t, blue, colors[0])
+	assert.Equal(t, red, colors[2])
+}
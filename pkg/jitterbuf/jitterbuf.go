@@ -0,0 +1,127 @@
+package jitterbuf
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Buffer holds values that are scheduled for playout at a specific point in
+// time. Values are released in order of their presentation time regardless of
+// the order they were pushed in, which absorbs network jitter as long as they
+// arrive before they are due.
+type Buffer[T any] struct {
+	mux   sync.Mutex
+	size  int
+	late  time.Duration
+	items []item[T]
+	wake  chan struct{}
+}
+
+type item[T any] struct {
+	at    time.Time
+	value T
+}
+
+// New creates a Buffer that holds up to size values. Values that are due for
+// longer than late by the time they are played out are discarded.
+func New[T any](size int, late time.Duration) *Buffer[T] {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Buffer[T]{
+		size:  size,
+		late:  late,
+		items: make([]item[T], 0, size),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Push schedules v to be played out at the given time. If the buffer is full
+// the value that is due the soonest is dropped to make room.
+func (b *Buffer[T]) Push(at time.Time, v T) {
+	b.mux.Lock()
+
+	if len(b.items) == b.size {
+		b.items = b.items[1:]
+	}
+
+	i := sort.Search(len(b.items), func(i int) bool {
+		return b.items[i].at.After(at)
+	})
+
+	b.items = append(b.items, item[T]{})
+	copy(b.items[i+1:], b.items[i:])
+	b.items[i] = item[T]{at: at, value: v}
+
+	b.mux.Unlock()
+
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of values waiting for playout.
+func (b *Buffer[T]) Len() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	return len(b.items)
+}
+
+// Run calls fn for each value at its scheduled time until the context is
+// canceled.
+func (b *Buffer[T]) Run(ctx context.Context, fn func(v T)) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		b.mux.Lock()
+
+		if len(b.items) == 0 {
+			b.mux.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.wake:
+			}
+
+			continue
+		}
+
+		next := b.items[0]
+		wait := time.Until(next.at)
+
+		if wait <= 0 {
+			b.items = b.items[1:]
+			b.mux.Unlock()
+
+			if -wait <= b.late {
+				fn(next.value)
+			}
+
+			continue
+		}
+
+		b.mux.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package jitterbuf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// play runs the buffer until n values were played out or the timeout passed.
+func play(b *Buffer[int], n int, timeout time.Duration) []int {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var played []int
+	b.Run(ctx, func(v int) {
+		played = append(played, v)
+		if len(played) == n {
+			cancel()
+		}
+	})
+
+	return played
+}
+
+func TestBufferOrder(t *testing.T) {
+	b := New[int](8, time.Second)
+	now := time.Now()
+
+	// frames arrive out of order but play out by their presentation time
+	b.Push(now.Add(30*time.Millisecond), 3)
+	b.Push(now.Add(10*time.Millisecond), 1)
+	b.Push(now.Add(20*time.Millisecond), 2)
+	assert.Equal(t, 3, b.Len())
+
+	assert.Equal(t, []int{1, 2, 3}, play(b, 3, time.Second))
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestBufferLate(t *testing.T) {
+	b := New[int](8, 50*time.Millisecond)
+	now := time.Now()
+
+	// frames that are overdue by more than the tolerance are dropped
+	b.Push(now.Add(-time.Second), 1)
+	b.Push(now.Add(-10*time.Millisecond), 2)
+	b.Push(now.Add(10*time.Millisecond), 3)
+
+	assert.Equal(t, []int{2, 3}, play(b, 2, time.Second))
+}
+
+func TestBufferFull(t *testing.T) {
+	b := New[int](2, time.Second)
+	now := time.Now()
+
+	// a full buffer makes room by dropping the frame that is due first
+	b.Push(now.Add(10*time.Millisecond), 1)
+	b.Push(now.Add(20*time.Millisecond), 2)
+	b.Push(now.Add(30*time.Millisecond), 3)
+	assert.Equal(t, 2, b.Len())
+
+	assert.Equal(t, []int{2, 3}, play(b, 2, time.Second))
+}
@@ -0,0 +1,337 @@
+package layout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// defaultDepth is how far into the screen LEDs on lines and paths sample by
+// default, relative to the screen.
+const defaultDepth = 0.1
+
+// Layout places the LEDs of segments on a screen. Coordinates are relative to
+// the screen, from 0 to 1, with the origin at the top left corner, so that a
+// layout works at any resolution.
+type Layout struct {
+	Segments []Segment `json:"segments"`
+
+	// dir is the directory imports are relative to.
+	dir string
+}
+
+// Segment lists the LEDs of a segment in the order of the strip, as runs of
+// LEDs or imported from a generated layout.
+type Segment struct {
+	Id int `json:"id"`
+	// Import is the name of a generated layout with the LEDs of the segment,
+	// relative to the layout file. It is a JSON array of LEDs with hmin, hmax,
+	// vmin and vmax coordinates, optionally in a "leds" object, as written by
+	// HyperCon and similar tools. Runs follow the imported LEDs.
+	Import string `json:"import,omitempty"`
+	Runs   []Run  `json:"runs"`
+}
+
+// Run is a run of LEDs. It is either a number of LEDs to skip, LEDs with
+// explicit regions, or LEDs spread evenly along a line or an SVG path.
+type Run struct {
+	// Skip is an amount of LEDs that don't sample the screen, e.g. around a
+	// monitor stand. They stay black.
+	Skip int `json:"skip,omitempty"`
+
+	// Regions are the areas sampled by each LED.
+	Regions []Rect `json:"regions,omitempty"`
+
+	// From and To are the ends of a straight line.
+	From *Point `json:"from,omitempty"`
+	To   *Point `json:"to,omitempty"`
+	// Path is an SVG path with M, L, H, V, C, Q and Z commands. Its
+	// coordinates are relative to the screen, unless ViewBox gives the size of
+	// the screen in path units.
+	Path    string `json:"path,omitempty"`
+	ViewBox *Size  `json:"viewBox,omitempty"`
+	// Leds is the amount of LEDs on the line or path.
+	Leds int `json:"leds,omitempty"`
+	// Size is the size of the area each LED on the line or path samples. It
+	// defaults to the spacing between the LEDs along the line and a tenth of
+	// the screen across it. Areas are kept inside the screen.
+	Size *Size `json:"size,omitempty"`
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Size struct {
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+// Rect is an area of the screen. The zero Rect samples nothing.
+type Rect struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Empty reports whether the rectangle contains no area.
+func (r Rect) Empty() bool {
+	return r.X0 >= r.X1 || r.Y0 >= r.Y1
+}
+
+// Load reads a layout file.
+func Load(name string) (*Layout, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %s: %w", name, err)
+	}
+
+	l.dir = filepath.Dir(name)
+
+	return l, nil
+}
+
+// Parse reads a layout in JSON.
+func Parse(r io.Reader) (*Layout, error) {
+	var l Layout
+
+	err := json.NewDecoder(r).Decode(&l)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[int]bool{}
+	for _, seg := range l.Segments {
+		if ids[seg.Id] {
+			return nil, fmt.Errorf("duplicate segment %d", seg.Id)
+		}
+		ids[seg.Id] = true
+	}
+
+	return &l, nil
+}
+
+// Regions returns the areas sampled by the LEDs of a segment, in the order of
+// the strip. Skipped LEDs have empty regions. It returns false if the layout
+// doesn't place the segment.
+func (l *Layout) Regions(id int) ([]Rect, bool, error) {
+	for _, seg := range l.Segments {
+		if seg.Id != id {
+			continue
+		}
+
+		regions, err := l.regions(seg)
+		if err != nil {
+			return nil, true, fmt.Errorf("segment %d: %w", id, err)
+		}
+
+		return regions, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (l *Layout) regions(seg Segment) ([]Rect, error) {
+	var regions []Rect
+
+	if seg.Import != "" {
+		name := seg.Import
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(l.dir, name)
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		regions, err = Import(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import %s: %w", seg.Import, err)
+		}
+	}
+
+	for i, run := range seg.Runs {
+		rs, err := run.regions()
+		if err != nil {
+			return nil, fmt.Errorf("run %d: %w", i, err)
+		}
+
+		regions = append(regions, rs...)
+	}
+
+	for _, r := range regions {
+		if r.X0 < 0 || r.Y0 < 0 || r.X1 > 1 || r.Y1 > 1 {
+			return nil, errors.New("regions must be within the screen")
+		}
+	}
+
+	return regions, nil
+}
+
+func (r Run) regions() ([]Rect, error) {
+	switch {
+	case r.Skip < 0:
+		return nil, errors.New("skipped LEDs can't be negative")
+	case r.Skip > 0:
+		return make([]Rect, r.Skip), nil
+	case len(r.Regions) > 0:
+		return r.Regions, nil
+	}
+
+	if r.Leds < 1 {
+		return nil, errors.New("lines and paths need at least one LED")
+	}
+
+	var points []Point
+
+	switch {
+	case r.From != nil && r.To != nil:
+		points = []Point{*r.From, *r.To}
+	case r.Path != "":
+		var err error
+		points, err = parsePath(r.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.ViewBox != nil {
+			if r.ViewBox.W <= 0 || r.ViewBox.H <= 0 {
+				return nil, errors.New("invalid view box")
+			}
+
+			for i := range points {
+				points[i].X /= r.ViewBox.W
+				points[i].Y /= r.ViewBox.H
+			}
+		}
+	default:
+		return nil, errors.New("a run needs skip, regions, from and to, or a path")
+	}
+
+	return spread(points, r.Leds, r.Size), nil
+}
+
+// spread places LEDs evenly along a polyline and returns their regions.
+func spread(points []Point, leds int, size *Size) []Rect {
+	// lengths[i] is the distance along the line up to points[i]
+	lengths := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		lengths[i] = lengths[i-1] + dist(points[i-1], points[i])
+	}
+
+	total := lengths[len(lengths)-1]
+	spacing := total / float64(leds)
+
+	regions := make([]Rect, leds)
+	j := 1
+
+	for i := range regions {
+		d := (float64(i) + 0.5) * spacing
+
+		for j < len(points)-1 && lengths[j] < d {
+			j++
+		}
+
+		a, b := points[0], points[0]
+		if len(points) > 1 {
+			a, b = points[j-1], points[j]
+		}
+
+		var t float64
+		if seg := lengths[min(j, len(lengths)-1)] - lengths[j-1]; seg > 0 {
+			t = (d - lengths[j-1]) / seg
+		}
+
+		p := Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+
+		w, h := spacing, defaultDepth
+		if math.Abs(b.Y-a.Y) > math.Abs(b.X-a.X) {
+			// mostly vertical
+			w, h = h, w
+		}
+
+		if size != nil {
+			w, h = size.W, size.H
+		}
+
+		regions[i] = box(p, w, h)
+	}
+
+	return regions
+}
+
+// box returns a rectangle of the given size centered on p, moved inside the
+// screen.
+func box(p Point, w, h float64) Rect {
+	w, h = math.Min(w, 1), math.Min(h, 1)
+
+	x0 := math.Max(0, math.Min(1-w, p.X-w/2))
+	y0 := math.Max(0, math.Min(1-h, p.Y-h/2))
+
+	return Rect{X0: x0, Y0: y0, X1: x0 + w, Y1: y0 + h}
+}
+
+func dist(a, b Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// hyperionLed is an LED of a generated layout.
+type hyperionLed struct {
+	HMin float64 `json:"hmin"`
+	HMax float64 `json:"hmax"`
+	VMin float64 `json:"vmin"`
+	VMax float64 `json:"vmax"`
+}
+
+// Import reads the regions of a generated layout, a JSON array of LEDs with
+// hmin, hmax, vmin and vmax coordinates, optionally in a "leds" object.
+func Import(r io.Reader) ([]Rect, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var leds []hyperionLed
+
+	err = json.Unmarshal(b, &leds)
+	if err != nil {
+		var obj struct {
+			Leds []hyperionLed `json:"leds"`
+		}
+
+		if json.Unmarshal(b, &obj) != nil {
+			return nil, err
+		}
+
+		leds = obj.Leds
+	}
+
+	regions := make([]Rect, len(leds))
+	for i, led := range leds {
+		regions[i] = Rect{X0: led.HMin, Y0: led.VMin, X1: led.HMax, Y1: led.VMax}
+	}
+
+	return regions, nil
+}
@@ -0,0 +1,116 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayoutRuns(t *testing.T) {
+	l, err := Parse(strings.NewReader(`{
+		"segments": [{
+			"id": 1,
+			"runs": [
+				{"from": {"x": 0, "y": 0}, "to": {"x": 1, "y": 0}, "leds": 4},
+				{"skip": 2},
+				{"regions": [{"x0": 0.4, "y0": 0.4, "x1": 0.6, "y1": 0.6}]}
+			]
+		}]
+	}`))
+	assert.Nil(t, err)
+
+	regions, ok, err := l.Regions(1)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Len(t, regions, 7)
+
+	// LEDs on a line sample the spacing between them, moved inside the screen
+	assert.InDeltaSlice(t, []float64{0, 0, 0.25, 0.1}, []float64{regions[0].X0, regions[0].Y0, regions[0].X1, regions[0].Y1}, 1e-9)
+	assert.InDeltaSlice(t, []float64{0.75, 0, 1, 0.1}, []float64{regions[3].X0, regions[3].Y0, regions[3].X1, regions[3].Y1}, 1e-9)
+
+	// skipped LEDs sample nothing
+	assert.True(t, regions[4].Empty())
+	assert.True(t, regions[5].Empty())
+	assert.Equal(t, Rect{X0: 0.4, Y0: 0.4, X1: 0.6, Y1: 0.6}, regions[6])
+
+	_, ok, err = l.Regions(2)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestLayoutPath(t *testing.T) {
+	// a path around the right and bottom edges of a 1920x1080 screen
+	l, err := Parse(strings.NewReader(`{
+		"segments": [{
+			"id": 0,
+			"runs": [{
+				"path": "M1920,0 V1080 h-1920",
+				"viewBox": {"w": 1920, "h": 1080},
+				"leds": 2,
+				"size": {"w": 0.1, "h": 0.1}
+			}]
+		}]
+	}`))
+	assert.Nil(t, err)
+
+	regions, _, err := l.Regions(0)
+	assert.Nil(t, err)
+	assert.Len(t, regions, 2)
+
+	// the LEDs are spread by length, so the first is on the right edge and
+	// the second on the bottom one
+	assert.InDelta(t, 0.9, regions[0].X0, 1e-9)
+	assert.InDelta(t, 0.9, regions[1].Y0, 1e-9)
+	assert.Less(t, regions[1].X1, 0.9)
+
+	// curves are flattened, and bad paths are rejected
+	for path, valid := range map[string]bool{
+		"M0 0 Q0.5 1 1 0":     true,
+		"m0 0 c0 1 1 1 1 0 z": true,
+		"0 0 L1 1":            false,
+		"M0 0 A1 1 0 0 1 1 1": false,
+		"M0 0 L1":             false,
+		"M0 0 L1 0 M0 1 L1 1": false,
+	} {
+		l := Layout{Segments: []Segment{{Runs: []Run{{Path: path, Leds: 3}}}}}
+		_, _, err := l.Regions(0)
+		assert.Equal(t, valid, err == nil, path)
+	}
+}
+
+func TestLayoutImport(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "generated.json"), []byte(`{
+		"leds": [
+			{"hmin": 0, "hmax": 0.5, "vmin": 0, "vmax": 0.08},
+			{"hmin": 0.5, "hmax": 1, "vmin": 0, "vmax": 0.08}
+		]
+	}`), 0o644)
+	assert.Nil(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "json"), []byte(`{
+		"segments": [{"id": 3, "import": "generated.json", "runs": [{"skip": 1}]}]
+	}`), 0o644)
+	assert.Nil(t, err)
+
+	l, err := Load(filepath.Join(dir, "json"))
+	assert.Nil(t, err)
+
+	regions, ok, err := l.Regions(3)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []Rect{
+		{X0: 0, Y0: 0, X1: 0.5, Y1: 0.08},
+		{X0: 0.5, Y0: 0, X1: 1, Y1: 0.08},
+		{},
+	}, regions)
+
+	// plain arrays import as well
+	regions, err = Import(strings.NewReader(`[{"hmin": 0.1, "hmax": 0.2, "vmin": 0.3, "vmax": 0.4}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Rect{{X0: 0.1, Y0: 0.3, X1: 0.2, Y1: 0.4}}, regions)
+}
@@ -0,0 +1,222 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// curveSteps is the amount of line segments curves are flattened into.
+const curveSteps = 16
+
+// parsePath flattens an SVG path into a polyline. Only a single subpath is
+// supported; LEDs run along it in the order it is drawn.
+func parsePath(d string) ([]Point, error) {
+	tokens, err := tokenize(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	var cur, start Point
+	var cmd byte
+
+	next := func(n int) ([]float64, error) {
+		if len(tokens) < n {
+			return nil, fmt.Errorf("command %c needs %d coordinates", cmd, n)
+		}
+
+		args := make([]float64, n)
+		for i := range args {
+			if tokens[i].cmd != 0 {
+				return nil, fmt.Errorf("command %c needs %d coordinates", cmd, n)
+			}
+			args[i] = tokens[i].num
+		}
+		tokens = tokens[n:]
+
+		return args, nil
+	}
+
+	for len(tokens) > 0 {
+		if tokens[0].cmd != 0 {
+			cmd = tokens[0].cmd
+			tokens = tokens[1:]
+		} else if cmd == 0 {
+			return nil, errors.New("path must start with a command")
+		}
+
+		rel := unicode.IsLower(rune(cmd))
+		abs := func(x, y float64) Point {
+			if rel {
+				return Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return Point{X: x, Y: y}
+		}
+
+		switch unicode.ToUpper(rune(cmd)) {
+		case 'M':
+			if len(points) > 0 {
+				return nil, errors.New("paths with several subpaths are not supported")
+			}
+
+			args, err := next(2)
+			if err != nil {
+				return nil, err
+			}
+
+			cur = abs(args[0], args[1])
+			start = cur
+			points = append(points, cur)
+
+			// further coordinate pairs are lines
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L':
+			args, err := next(2)
+			if err != nil {
+				return nil, err
+			}
+
+			cur = abs(args[0], args[1])
+			points = append(points, cur)
+		case 'H':
+			args, err := next(1)
+			if err != nil {
+				return nil, err
+			}
+
+			if rel {
+				cur.X += args[0]
+			} else {
+				cur.X = args[0]
+			}
+			points = append(points, cur)
+		case 'V':
+			args, err := next(1)
+			if err != nil {
+				return nil, err
+			}
+
+			if rel {
+				cur.Y += args[0]
+			} else {
+				cur.Y = args[0]
+			}
+			points = append(points, cur)
+		case 'C':
+			args, err := next(6)
+			if err != nil {
+				return nil, err
+			}
+
+			p0 := cur
+			p1, p2, p3 := abs(args[0], args[1]), abs(args[2], args[3]), abs(args[4], args[5])
+
+			for i := 1; i <= curveSteps; i++ {
+				t := float64(i) / curveSteps
+				u := 1 - t
+				points = append(points, Point{
+					X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+					Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+				})
+			}
+			cur = p3
+		case 'Q':
+			args, err := next(4)
+			if err != nil {
+				return nil, err
+			}
+
+			p0 := cur
+			p1, p2 := abs(args[0], args[1]), abs(args[2], args[3])
+
+			for i := 1; i <= curveSteps; i++ {
+				t := float64(i) / curveSteps
+				u := 1 - t
+				points = append(points, Point{
+					X: u*u*p0.X + 2*u*t*p1.X + t*t*p2.X,
+					Y: u*u*p0.Y + 2*u*t*p1.Y + t*t*p2.Y,
+				})
+			}
+			cur = p2
+		case 'Z':
+			cur = start
+			points = append(points, cur)
+
+			if len(tokens) > 0 {
+				return nil, errors.New("paths with several subpaths are not supported")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported path command %c", cmd)
+		}
+
+		if len(points) == 0 {
+			return nil, errors.New("path must start with a move")
+		}
+	}
+
+	if len(points) < 2 {
+		return nil, errors.New("path must have at least two points")
+	}
+
+	return points, nil
+}
+
+type token struct {
+	cmd byte
+	num float64
+}
+
+// tokenize splits a path into commands and numbers.
+func tokenize(d string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(d); {
+		c := d[i]
+
+		switch {
+		case c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("MmLlHhVvCcQqZz", c) >= 0:
+			tokens = append(tokens, token{cmd: c})
+			i++
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			j := i + 1
+			dot := c == '.'
+			for j < len(d) {
+				b := d[j]
+				if b >= '0' && b <= '9' {
+					j++
+				} else if b == '.' && !dot {
+					dot = true
+					j++
+				} else if (b == 'e' || b == 'E') && j+1 < len(d) {
+					j++
+					if d[j] == '-' || d[j] == '+' {
+						j++
+					}
+				} else {
+					break
+				}
+			}
+
+			num, err := strconv.ParseFloat(d[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q in path", d[i:j])
+			}
+
+			tokens = append(tokens, token{num: num})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q in path", c)
+		}
+	}
+
+	return tokens, nil
+}
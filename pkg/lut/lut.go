@@ -0,0 +1,318 @@
+package lut
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Interpolation is the method used to sample the LUT between lattice points.
+type Interpolation string
+
+const (
+	Trilinear   Interpolation = "trilinear"
+	Tetrahedral Interpolation = "tetrahedral"
+)
+
+var interpolations = map[string]Interpolation{
+	"trilinear":   Trilinear,
+	"tetrahedral": Tetrahedral,
+}
+
+var ErrInvalidInterpolation = errors.New("invalid interpolation")
+
+// ParseInterpolation parses an interpolation method by name. An empty name
+// selects tetrahedral interpolation.
+func ParseInterpolation(s string) (Interpolation, error) {
+	if s == "" {
+		return Tetrahedral, nil
+	}
+
+	i, ok := interpolations[s]
+	if !ok {
+		return "", ErrInvalidInterpolation
+	}
+
+	return i, nil
+}
+
+// LUT is a 3D color lookup table. Colors are stored with the red index
+// changing fastest, as in the .cube format.
+type LUT struct {
+	Title     string
+	Size      int
+	DomainMin [3]float64
+	DomainMax [3]float64
+	Data      [][3]float64
+}
+
+// Load reads a .cube file from disk.
+func Load(name string) (*LUT, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return l, nil
+}
+
+// Parse decodes a LUT in the .cube format.
+func Parse(r io.Reader) (*LUT, error) {
+	l := &LUT{
+		DomainMax: [3]float64{1, 1, 1},
+	}
+
+	sc := bufio.NewScanner(r)
+	line := 0
+
+	for sc.Scan() {
+		line++
+
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+
+		fields := strings.Fields(s)
+
+		switch fields[0] {
+		case "TITLE":
+			l.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(s, "TITLE")), `"`)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE", line)
+			}
+
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 || size > 256 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE", line)
+			}
+
+			l.Size = size
+			l.Data = make([][3]float64, 0, size*size*size)
+		case "LUT_1D_SIZE":
+			return nil, errors.New("1D LUTs are not supported")
+		case "DOMAIN_MIN":
+			v, err := parseTriplet(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DOMAIN_MIN: %w", line, err)
+			}
+
+			l.DomainMin = v
+		case "DOMAIN_MAX":
+			v, err := parseTriplet(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DOMAIN_MAX: %w", line, err)
+			}
+
+			l.DomainMax = v
+		default:
+			if l.Size == 0 {
+				return nil, fmt.Errorf("line %d: data before LUT_3D_SIZE", line)
+			}
+
+			v, err := parseTriplet(fields)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+
+			if len(l.Data) == cap(l.Data) {
+				return nil, fmt.Errorf("line %d: too many entries", line)
+			}
+
+			l.Data = append(l.Data, v)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if l.Size == 0 {
+		return nil, errors.New("missing LUT_3D_SIZE")
+	}
+
+	if len(l.Data) != l.Size*l.Size*l.Size {
+		return nil, fmt.Errorf("expected %d entries, got %d", l.Size*l.Size*l.Size, len(l.Data))
+	}
+
+	for i := 0; i < 3; i++ {
+		if l.DomainMax[i] <= l.DomainMin[i] {
+			return nil, errors.New("invalid domain")
+		}
+	}
+
+	return l, nil
+}
+
+func parseTriplet(fields []string) ([3]float64, error) {
+	var v [3]float64
+
+	if len(fields) != 3 {
+		return v, errors.New("expected three values")
+	}
+
+	for i, f := range fields {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return v, err
+		}
+
+		v[i] = n
+	}
+
+	return v, nil
+}
+
+func (l *LUT) at(r, g, b int) [3]float64 {
+	return l.Data[r+g*l.Size+b*l.Size*l.Size]
+}
+
+// Lookup samples the LUT for a color with channels in the range [0, 1].
+func (l *LUT) Lookup(r, g, b float64, interp Interpolation) (float64, float64, float64) {
+	var idx [3]int
+	var frac [3]float64
+
+	for i, v := range [3]float64{r, g, b} {
+		idx[i], frac[i] = l.locate(i, v)
+	}
+
+	var c [3]float64
+	if interp == Trilinear {
+		c = trilinear(l, idx, frac)
+	} else {
+		c = tetrahedral(l, idx, frac)
+	}
+
+	return c[0], c[1], c[2]
+}
+
+// locate returns the lower lattice index along an axis and the fractional
+// position between it and the next one.
+func (l *LUT) locate(axis int, v float64) (int, float64) {
+	v = (v - l.DomainMin[axis]) / (l.DomainMax[axis] - l.DomainMin[axis])
+	v = math.Max(0, math.Min(1, v)) * float64(l.Size-1)
+
+	i := int(v)
+	if i >= l.Size-1 {
+		return l.Size - 2, 1
+	}
+
+	return i, v - float64(i)
+}
+
+func trilinear(l *LUT, idx [3]int, f [3]float64) [3]float64 {
+	r, g, b := idx[0], idx[1], idx[2]
+	fr, fg, fb := f[0], f[1], f[2]
+
+	var out [3]float64
+	for i := 0; i < 3; i++ {
+		c00 := lerp(l.at(r, g, b)[i], l.at(r+1, g, b)[i], fr)
+		c10 := lerp(l.at(r, g+1, b)[i], l.at(r+1, g+1, b)[i], fr)
+		c01 := lerp(l.at(r, g, b+1)[i], l.at(r+1, g, b+1)[i], fr)
+		c11 := lerp(l.at(r, g+1, b+1)[i], l.at(r+1, g+1, b+1)[i], fr)
+
+		out[i] = lerp(lerp(c00, c10, fg), lerp(c01, c11, fg), fb)
+	}
+
+	return out
+}
+
+func tetrahedral(l *LUT, idx [3]int, f [3]float64) [3]float64 {
+	r, g, b := idx[0], idx[1], idx[2]
+	fr, fg, fb := f[0], f[1], f[2]
+
+	c000 := l.at(r, g, b)
+	c111 := l.at(r+1, g+1, b+1)
+
+	// Each tetrahedron is spanned by c000, c111 and the two lattice points
+	// on the path between them that follow the largest fractional offsets.
+	var w [4]float64
+	var c1, c2 [3]float64
+
+	switch {
+	case fr > fg && fg > fb:
+		c1, c2 = l.at(r+1, g, b), l.at(r+1, g+1, b)
+		w = [4]float64{1 - fr, fr - fg, fg - fb, fb}
+	case fr > fb && fb >= fg:
+		c1, c2 = l.at(r+1, g, b), l.at(r+1, g, b+1)
+		w = [4]float64{1 - fr, fr - fb, fb - fg, fg}
+	case fb >= fr && fr > fg:
+		c1, c2 = l.at(r, g, b+1), l.at(r+1, g, b+1)
+		w = [4]float64{1 - fb, fb - fr, fr - fg, fg}
+	case fb > fg && fg >= fr:
+		c1, c2 = l.at(r, g, b+1), l.at(r, g+1, b+1)
+		w = [4]float64{1 - fb, fb - fg, fg - fr, fr}
+	case fg >= fb && fb > fr:
+		c1, c2 = l.at(r, g+1, b), l.at(r, g+1, b+1)
+		w = [4]float64{1 - fg, fg - fb, fb - fr, fr}
+	default:
+		c1, c2 = l.at(r, g+1, b), l.at(r+1, g+1, b)
+		w = [4]float64{1 - fg, fg - fr, fr - fb, fb}
+	}
+
+	var out [3]float64
+	for i := 0; i < 3; i++ {
+		out[i] = w[0]*c000[i] + w[1]*c1[i] + w[2]*c2[i] + w[3]*c111[i]
+	}
+
+	return out
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// Identity creates a LUT of the given size that leaves colors unchanged. It
+// is a starting point for LUTs generated from a calibration session.
+func Identity(size int) *LUT {
+	l := &LUT{
+		Size:      size,
+		DomainMax: [3]float64{1, 1, 1},
+		Data:      make([][3]float64, 0, size*size*size),
+	}
+
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				l.Data = append(l.Data, [3]float64{
+					float64(r) / float64(size-1),
+					float64(g) / float64(size-1),
+					float64(b) / float64(size-1),
+				})
+			}
+		}
+	}
+
+	return l
+}
+
+// Encode writes the LUT in the .cube format.
+func (l *LUT) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if l.Title != "" {
+		fmt.Fprintf(bw, "TITLE %q\n", l.Title)
+	}
+
+	fmt.Fprintf(bw, "LUT_3D_SIZE %d\n", l.Size)
+	fmt.Fprintf(bw, "DOMAIN_MIN %g %g %g\n", l.DomainMin[0], l.DomainMin[1], l.DomainMin[2])
+	fmt.Fprintf(bw, "DOMAIN_MAX %g %g %g\n", l.DomainMax[0], l.DomainMax[1], l.DomainMax[2])
+
+	for _, c := range l.Data {
+		fmt.Fprintf(bw, "%.6f %.6f %.6f\n", c[0], c[1], c[2])
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,143 @@
+package lut
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Identity(5).Encode(&buf)
+	assert.Nil(t, err)
+
+	l, err := Parse(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, l.Size)
+	assert.Len(t, l.Data, 125)
+
+	for _, interp := range []Interpolation{Trilinear, Tetrahedral} {
+		tbl := l.Compile(interp)
+
+		for _, c := range [][3]float64{{0, 0, 0}, {1, 1, 1}, {0.3, 0.6, 0.9}, {0.9, 0.1, 0.5}} {
+			r, g, b := l.Lookup(c[0], c[1], c[2], interp)
+			assert.InDelta(t, c[0], r, 1e-6, interp)
+			assert.InDelta(t, c[1], g, 1e-6, interp)
+			assert.InDelta(t, c[2], b, 1e-6, interp)
+		}
+
+		for _, c := range [][3]uint16{{0, 0, 0}, {0xffff, 0xffff, 0xffff}, {0x1234, 0x8000, 0xfedc}} {
+			r, g, b := tbl.Apply(c[0], c[1], c[2])
+			assert.InDelta(t, c[0], r, 2, interp)
+			assert.InDelta(t, c[1], g, 2, interp)
+			assert.InDelta(t, c[2], b, 2, interp)
+		}
+	}
+}
+
+func TestInvertCube(t *testing.T) {
+	cube := `TITLE "invert"
+# red changes fastest
+LUT_3D_SIZE 2
+1 1 1
+0 1 1
+1 0 1
+0 0 1
+1 1 0
+0 1 0
+1 0 0
+0 0 0
+`
+
+	l, err := Parse(strings.NewReader(cube))
+	assert.Nil(t, err)
+	assert.Equal(t, "invert", l.Title)
+
+	for _, interp := range []Interpolation{Trilinear, Tetrahedral} {
+		r, g, b := l.Lookup(0.25, 0.5, 1, interp)
+		assert.InDelta(t, 0.75, r, 1e-9, interp)
+		assert.InDelta(t, 0.5, g, 1e-9, interp)
+		assert.InDelta(t, 0, b, 1e-9, interp)
+
+		r16, g16, b16 := l.Compile(interp).Apply(0x4000, 0x8000, 0xffff)
+		assert.InDelta(t, 0xbfff, r16, 2, interp)
+		assert.InDelta(t, 0x7fff, g16, 2, interp)
+		assert.InDelta(t, 0, b16, 2, interp)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	// an identity cube with white pulled down to gray, where the two
+	// interpolations differ on the gray axis
+	l := Identity(2)
+	l.Data[7] = [3]float64{0.5, 0.5, 0.5}
+
+	r, _, _ := l.Lookup(0.5, 0.5, 0.5, Trilinear)
+	assert.InDelta(t, 0.4375, r, 1e-9)
+
+	r, _, _ = l.Lookup(0.5, 0.5, 0.5, Tetrahedral)
+	assert.InDelta(t, 0.25, r, 1e-9)
+
+	r16, _, _ := l.Compile(Trilinear).Apply(0x8000, 0x8000, 0x8000)
+	assert.InDelta(t, 0.4375*0xffff, r16, 16)
+
+	r16, _, _ = l.Compile(Tetrahedral).Apply(0x8000, 0x8000, 0x8000)
+	assert.InDelta(t, 0.25*0xffff, r16, 16)
+}
+
+func TestDomain(t *testing.T) {
+	cube := "LUT_3D_SIZE 2\nDOMAIN_MIN 0 0 0\nDOMAIN_MAX 2 2 2\n" +
+		strings.Repeat("0.5 0.5 0.5\n", 8)
+
+	l, err := Parse(strings.NewReader(cube))
+	assert.Nil(t, err)
+	assert.Equal(t, [3]float64{2, 2, 2}, l.DomainMax)
+
+	l = Identity(3)
+	l.DomainMax = [3]float64{2, 2, 2}
+
+	r, g, b := l.Lookup(1, 0.5, 4, Trilinear)
+	assert.InDelta(t, 0.5, r, 1e-9)
+	assert.InDelta(t, 0.25, g, 1e-9)
+	assert.InDelta(t, 1, b, 1e-9)
+}
+
+func TestParseErrors(t *testing.T) {
+	entries := strings.Repeat("0 0 0\n", 8)
+
+	for name, cube := range map[string]string{
+		"missing size":   entries,
+		"size too small": "LUT_3D_SIZE 1\n0 0 0\n",
+		"size too large": "LUT_3D_SIZE 257\n",
+		"size not int":   "LUT_3D_SIZE two\n",
+		"size args":      "LUT_3D_SIZE 2 2\n",
+		"1d":             "LUT_1D_SIZE 2\n",
+		"few entries":    "LUT_3D_SIZE 2\n" + strings.Repeat("0 0 0\n", 7),
+		"many entries":   "LUT_3D_SIZE 2\n" + strings.Repeat("0 0 0\n", 9),
+		"bad entry":      "LUT_3D_SIZE 2\n0 0\n",
+		"bad number":     "LUT_3D_SIZE 2\n0 0 x\n",
+		"bad domain min": "DOMAIN_MIN 0 0\nLUT_3D_SIZE 2\n" + entries,
+		"bad domain max": "DOMAIN_MAX 1 1 one\nLUT_3D_SIZE 2\n" + entries,
+		"empty domain":   "DOMAIN_MIN 1 0 0\nLUT_3D_SIZE 2\n" + entries,
+		"flipped domain": "DOMAIN_MIN 0 0 0\nDOMAIN_MAX 1 -1 1\nLUT_3D_SIZE 2\n" + entries,
+	} {
+		_, err := Parse(strings.NewReader(cube))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestParseInterpolation(t *testing.T) {
+	i, err := ParseInterpolation("")
+	assert.Nil(t, err)
+	assert.Equal(t, Tetrahedral, i)
+
+	i, err = ParseInterpolation("trilinear")
+	assert.Nil(t, err)
+	assert.Equal(t, Trilinear, i)
+
+	_, err = ParseInterpolation("cubic")
+	assert.ErrorIs(t, err, ErrInvalidInterpolation)
+}
@@ -0,0 +1,137 @@
+package lut
+
+import (
+	"math"
+)
+
+// weightBits is the fixed-point precision of the interpolation weights.
+const weightBits = 12
+
+// Table is a compiled LUT. The lattice is stored as fixed-point integers and
+// the mapping from input levels to lattice positions is precomputed, so a
+// lookup mostly needs integer arithmetic.
+type Table struct {
+	size   int
+	interp Interpolation
+	data   [][3]uint16
+	// min and scale map a 16-bit input level of each axis to its position
+	// on the lattice.
+	min   [3]float64
+	scale [3]float64
+}
+
+// Compile precomputes the LUT for 16-bit input using the given interpolation.
+func (l *LUT) Compile(interp Interpolation) *Table {
+	t := &Table{
+		size:   l.Size,
+		interp: interp,
+		data:   make([][3]uint16, len(l.Data)),
+	}
+
+	for i, c := range l.Data {
+		for j, v := range c {
+			v = math.Max(0, math.Min(1, v))
+			t.data[i][j] = uint16(math.Round(v * 0xffff))
+		}
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		t.min[axis] = l.DomainMin[axis] * 0xffff
+		t.scale[axis] = float64(l.Size-1) / ((l.DomainMax[axis] - l.DomainMin[axis]) * 0xffff)
+	}
+
+	return t
+}
+
+func (t *Table) at(r, g, b int) [3]uint16 {
+	return t.data[r+g*t.size+b*t.size*t.size]
+}
+
+// locate returns the lower lattice index along an axis and the fixed-point
+// fractional position between it and the next one.
+func (t *Table) locate(axis int, v uint16) (int, uint32) {
+	p := (float64(v) - t.min[axis]) * t.scale[axis]
+	p = math.Max(0, math.Min(float64(t.size-1), p))
+
+	i := int(p)
+	if i >= t.size-1 {
+		return t.size - 2, one
+	}
+
+	return i, uint32((p - float64(i)) * one)
+}
+
+// Apply maps a 16-bit color through the table.
+func (t *Table) Apply(r, g, b uint16) (uint16, uint16, uint16) {
+	ri, fr := t.locate(0, r)
+	gi, fg := t.locate(1, g)
+	bi, fb := t.locate(2, b)
+
+	var c [3]uint32
+	if t.interp == Trilinear {
+		c = t.trilinear(ri, gi, bi, fr, fg, fb)
+	} else {
+		c = t.tetrahedral(ri, gi, bi, fr, fg, fb)
+	}
+
+	return uint16(c[0]), uint16(c[1]), uint16(c[2])
+}
+
+const one = 1 << weightBits
+
+func (t *Table) trilinear(r, g, b int, fr, fg, fb uint32) [3]uint32 {
+	var out [3]uint32
+
+	for i := 0; i < 3; i++ {
+		c00 := mix(t.at(r, g, b)[i], t.at(r+1, g, b)[i], fr)
+		c10 := mix(t.at(r, g+1, b)[i], t.at(r+1, g+1, b)[i], fr)
+		c01 := mix(t.at(r, g, b+1)[i], t.at(r+1, g, b+1)[i], fr)
+		c11 := mix(t.at(r, g+1, b+1)[i], t.at(r+1, g+1, b+1)[i], fr)
+
+		out[i] = uint32(mix(mix(c00, c10, fg), mix(c01, c11, fg), fb))
+	}
+
+	return out
+}
+
+func (t *Table) tetrahedral(r, g, b int, fr, fg, fb uint32) [3]uint32 {
+	c000 := t.at(r, g, b)
+	c111 := t.at(r+1, g+1, b+1)
+
+	var w [4]uint32
+	var c1, c2 [3]uint16
+
+	switch {
+	case fr > fg && fg > fb:
+		c1, c2 = t.at(r+1, g, b), t.at(r+1, g+1, b)
+		w = [4]uint32{one - fr, fr - fg, fg - fb, fb}
+	case fr > fb && fb >= fg:
+		c1, c2 = t.at(r+1, g, b), t.at(r+1, g, b+1)
+		w = [4]uint32{one - fr, fr - fb, fb - fg, fg}
+	case fb >= fr && fr > fg:
+		c1, c2 = t.at(r, g, b+1), t.at(r+1, g, b+1)
+		w = [4]uint32{one - fb, fb - fr, fr - fg, fg}
+	case fb > fg && fg >= fr:
+		c1, c2 = t.at(r, g, b+1), t.at(r, g+1, b+1)
+		w = [4]uint32{one - fb, fb - fg, fg - fr, fr}
+	case fg >= fb && fb > fr:
+		c1, c2 = t.at(r, g+1, b), t.at(r, g+1, b+1)
+		w = [4]uint32{one - fg, fg - fb, fb - fr, fr}
+	default:
+		c1, c2 = t.at(r, g+1, b), t.at(r+1, g+1, b)
+		w = [4]uint32{one - fg, fg - fr, fr - fb, fb}
+	}
+
+	var out [3]uint32
+	for i := 0; i < 3; i++ {
+		sum := w[0]*uint32(c000[i]) + w[1]*uint32(c1[i]) + w[2]*uint32(c2[i]) + w[3]*uint32(c111[i])
+		out[i] = (sum + one/2) >> weightBits
+	}
+
+	return out
+}
+
+// mix linearly interpolates between two fixed-point values.
+func mix(a, b uint16, f uint32) uint16 {
+	return uint16((uint32(a)*(one-f) + uint32(b)*f + one/2) >> weightBits)
+}
@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// maxDelay is how late a job can run. Jobs that are due for longer, e.g. after
+// the system clock was set on boot or the system was suspended, are skipped.
+const maxDelay = time.Minute
+
+// maxWait is the longest the scheduler waits before it checks the clock again,
+// so that it notices when the clock is changed.
+const maxWait = time.Minute
+
+// Trigger decides when a job runs.
+type Trigger interface {
+	// Next returns the first time the job runs after the given time, or the
+	// zero time if it never does.
+	Next(after time.Time) time.Time
+}
+
+// Clock tells the time and waits for it. It can be replaced to test the
+// scheduler.
+type Clock interface {
+	Now() time.Time
+	// At returns a channel that receives the time once it is at or after t.
+	At(t time.Time) <-chan time.Time
+}
+
+type systemClock struct{}
+
+// SystemClock is the clock of the system.
+var SystemClock Clock = systemClock{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) At(t time.Time) <-chan time.Time {
+	d := time.Until(t)
+	if d > maxWait {
+		d = maxWait
+	}
+
+	return time.After(d)
+}
+
+type Job struct {
+	Trigger Trigger
+	Run     func()
+}
+
+// Scheduler runs jobs at the times their triggers give.
+type Scheduler struct {
+	clock Clock
+	jobs  []Job
+}
+
+func New(clock Clock, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		clock: clock,
+		jobs:  jobs,
+	}
+}
+
+// Run runs the jobs until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	now := s.clock.Now()
+
+	next := make([]time.Time, len(s.jobs))
+	for i, job := range s.jobs {
+		next[i] = job.Trigger.Next(now)
+	}
+
+	for {
+		var wake time.Time
+		for _, t := range next {
+			if !t.IsZero() && (wake.IsZero() || t.Before(wake)) {
+				wake = t
+			}
+		}
+
+		var at <-chan time.Time
+		if !wake.IsZero() {
+			at = s.clock.At(wake)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-at:
+		}
+
+		last := now
+		now = s.clock.Now()
+
+		if now.Before(last) {
+			// the clock was set back
+			for i, job := range s.jobs {
+				next[i] = job.Trigger.Next(now)
+			}
+
+			continue
+		}
+
+		for i, job := range s.jobs {
+			if next[i].IsZero() || next[i].After(now) {
+				continue
+			}
+
+			if now.Sub(next[i]) <= maxDelay {
+				job.Run()
+			}
+
+			next[i] = job.Trigger.Next(now)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron(t *testing.T) {
+	c, err := ParseCron("0 23 * * *")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC),
+		c.Next(time.Date(2026, 10, 19, 22, 30, 0, 0, time.UTC)),
+	)
+	assert.Equal(t,
+		time.Date(2026, 10, 20, 23, 0, 0, 0, time.UTC),
+		c.Next(time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC)),
+	)
+
+	// 2026-10-24 is a saturday
+	c, err = ParseCron("*/15 9-17 * * mon-fri")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		time.Date(2026, 10, 26, 9, 0, 0, 0, time.UTC),
+		c.Next(time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC)),
+	)
+	assert.Equal(t,
+		time.Date(2026, 10, 26, 9, 15, 0, 0, time.UTC),
+		c.Next(time.Date(2026, 10, 26, 9, 0, 0, 0, time.UTC)),
+	)
+
+	// with both days restricted, either one matches
+	c, err = ParseCron("0 0 13 * fri")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC),
+		c.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)),
+	)
+
+	_, err = ParseCron("0 24 * * *")
+	assert.NotNil(t, err)
+
+	_, err = ParseCron("0 * *")
+	assert.NotNil(t, err)
+}
+
+func TestSunTimes(t *testing.T) {
+	// Berlin on the summer solstice: sunrise at 4:43 and sunset at 21:33
+	// local time
+	rise, set, ok := SunTimes(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 52.52, 13.405)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Date(2024, 6, 21, 2, 43, 0, 0, time.UTC), rise, 2*time.Minute)
+	assert.WithinDuration(t, time.Date(2024, 6, 21, 19, 33, 0, 0, time.UTC), set, 2*time.Minute)
+
+	// the sun doesn't set north of the arctic circle
+	_, _, ok = SunTimes(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 78.22, 15.65)
+	assert.False(t, ok)
+}
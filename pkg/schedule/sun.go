@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+type SunEvent string
+
+const (
+	Sunrise SunEvent = "sunrise"
+	Sunset  SunEvent = "sunset"
+)
+
+// Sun triggers at sunrise or sunset at a location, shifted by an offset.
+type Sun struct {
+	Event     SunEvent
+	Latitude  float64
+	Longitude float64
+	Offset    time.Duration
+}
+
+// NewSun returns a trigger for a sun event at the given location.
+func NewSun(event SunEvent, latitude, longitude float64, offset time.Duration) (*Sun, error) {
+	if event != Sunrise && event != Sunset {
+		return nil, errors.New("invalid sun event")
+	}
+
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, errors.New("invalid location")
+	}
+
+	return &Sun{
+		Event:     event,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Offset:    offset,
+	}, nil
+}
+
+// Next returns the first time of the event after the given time, or the zero
+// time if the sun doesn't rise or set within a year, e.g. near the poles.
+func (s *Sun) Next(after time.Time) time.Time {
+	for d := -1; d <= 366; d++ {
+		date := time.Date(after.Year(), after.Month(), after.Day()+d, 0, 0, 0, 0, after.Location())
+
+		rise, set, ok := SunTimes(date, s.Latitude, s.Longitude)
+		if !ok {
+			continue
+		}
+
+		t := rise
+		if s.Event == Sunset {
+			t = set
+		}
+
+		t = t.Add(s.Offset).In(after.Location())
+		if t.After(after) {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// SunTimes computes the times of sunrise and sunset on the date of the given
+// time at a location, with the sunrise equation. It reports false if the sun
+// doesn't rise or set on that day.
+func SunTimes(date time.Time, latitude, longitude float64) (rise, set time.Time, ok bool) {
+	rad := math.Pi / 180
+
+	// days since the J2000 epoch at noon UTC of the date
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noon.Unix())/86400+2440587.5) - 2451545
+
+	// mean solar noon
+	j := n + 0.0008 - longitude/360
+
+	// solar mean anomaly
+	m := math.Mod(357.5291+0.98560028*j, 360)
+
+	// equation of the center
+	c := 1.9148*math.Sin(m*rad) + 0.02*math.Sin(2*m*rad) + 0.0003*math.Sin(3*m*rad)
+
+	// ecliptic longitude
+	l := math.Mod(m+c+180+102.9372, 360)
+
+	transit := 2451545 + j + 0.0053*math.Sin(m*rad) - 0.0069*math.Sin(2*l*rad)
+
+	// declination of the sun
+	sinDecl := math.Sin(l*rad) * math.Sin(23.4397*rad)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+
+	// hour angle at which the upper limb touches the horizon, accounting
+	// for refraction
+	cosHour := (math.Sin(-0.833*rad) - math.Sin(latitude*rad)*sinDecl) / (math.Cos(latitude*rad) * cosDecl)
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	hour := math.Acos(cosHour) / rad
+
+	return julianTime(transit - hour/360), julianTime(transit + hour/360), true
+}
+
+func julianTime(jd float64) time.Time {
+	unix := (jd - 2440587.5) * 86400
+	return time.Unix(0, int64(unix*float64(time.Second))).UTC()
+}
@@ -0,0 +1,14 @@
+package ws281x
+
+// MaxChannels is the number of PWM channels available on the Raspberry Pi.
+const MaxChannels = 2
+
+// Channel configures one of the strips driven by an engine. The LEDs of all
+// channels are addressed as a single strip, with the LEDs of each channel
+// following the ones of the previous channel.
+type Channel struct {
+	GpioPin    int
+	LedCount   int
+	Brightness int
+	StripType  string
+}
@@ -0,0 +1,80 @@
+package ws281x
+
+import (
+	"errors"
+	"sync"
+)
+
+// Virtual is an LED strip that only exists in memory. It can be used to run
+// without the hardware, and to inspect the rendered frames in tests.
+type Virtual struct {
+	mux    sync.Mutex
+	leds   []uint32
+	frame  []uint32
+	frames int
+}
+
+// NewVirtual creates a virtual strip with the given amount of LEDs.
+func NewVirtual(ledsCount int) *Virtual {
+	return &Virtual{
+		leds:  make([]uint32, ledsCount),
+		frame: make([]uint32, ledsCount),
+	}
+}
+
+// Fini is a no-op
+func (v *Virtual) Fini() {}
+
+// Clear turns off all the leds
+func (v *Virtual) Clear() error {
+	v.mux.Lock()
+	v.leds = make([]uint32, len(v.leds))
+	v.mux.Unlock()
+
+	return v.Render()
+}
+
+// Render stores the colors saved on the leds array as the current frame
+func (v *Virtual) Render() error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	copy(v.frame, v.leds)
+	v.frames++
+
+	return nil
+}
+
+// SetLedColor changes the color of the led in the specified index
+func (v *Virtual) SetLedColor(index int, r uint8, g uint8, b uint8, w uint8) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if index >= len(v.leds) || index < 0 {
+		return errors.New("invalid led index")
+	}
+
+	v.leds[index] = uint32(w)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+
+	return nil
+}
+
+// Frame returns a copy of the last rendered frame. Each LED is encoded as
+// WRGB, like it is sent to a physical strip.
+func (v *Virtual) Frame() []uint32 {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	frame := make([]uint32, len(v.frame))
+	copy(frame, v.frame)
+
+	return frame
+}
+
+// Frames returns the amount of frames rendered so far.
+func (v *Virtual) Frames() int {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	return v.frames
+}
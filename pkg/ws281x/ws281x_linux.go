@@ -17,78 +17,55 @@ type Engine struct {
 	mux       sync.Mutex
 	LedsCount int
 	engine    *ws281x.WS2811
-	leds      []uint32
+	leds      [][]uint32
 	wg        *sync.WaitGroup
 	stop      chan struct{}
 	rendering bool
 }
 
-// Init initializes a new instance of the ws281x library
+// Init initializes a new instance of the ws281x library that drives a single
+// strip
 func Init(gpioPin int, ledCount int, brightness int, stripType string) (*Engine, error) {
+	return InitChannels(Channel{
+		GpioPin:    gpioPin,
+		LedCount:   ledCount,
+		Brightness: brightness,
+		StripType:  stripType,
+	})
+}
+
+// InitChannels initializes a new instance of the ws281x library that drives a
+// strip on each of the given channels
+func InitChannels(channels ...Channel) (*Engine, error) {
+	if len(channels) == 0 || len(channels) > MaxChannels {
+		return nil, errors.New("invalid channel count")
+	}
+
 	// Initialize ws281x engine
 	opt := ws281x.DefaultOptions
 	opt.Frequency = 800000
 	opt.RenderWaitTime = 0
-
-	opt.Channels[0].Brightness = brightness
-	opt.Channels[0].LedCount = ledCount
-	opt.Channels[0].GpioPin = gpioPin
-	//opt.Channels[0].Gamma = []uint8{
-	//	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
-	//	16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29,
-	//	30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43,
-	//	44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57,
-	//	58, 59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
-	//	72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 82, 83, 84, 85,
-	//	86, 87, 88, 89, 90, 91, 92, 93, 94, 95, 96, 97, 98, 99,
-	//	100, 101, 102, 103, 104, 105, 106, 107, 108, 109, 110, 111,
-	//	112, 113, 114, 115, 116, 117, 118, 119, 120, 121, 122, 123,
-	//	124, 125, 126, 127, 128, 129, 130, 131, 132, 133, 134, 135,
-	//	136, 137, 138, 139, 140, 141, 142, 143, 144, 145, 146, 147,
-	//	148, 149, 150, 151, 152, 153, 154, 155, 156, 157, 158, 159,
-	//	160, 161, 162, 163, 164, 165, 166, 167, 168, 169, 170, 171,
-	//	172, 173, 174, 175, 176, 177, 178, 179, 180, 181, 182, 183,
-	//	184, 185, 186, 187, 188, 189, 190, 191, 192, 193, 194, 195,
-	//	196, 197, 198, 199, 200, 201, 202, 203, 204, 205, 206, 207,
-	//	208, 209, 210, 211, 212, 213, 214, 215, 216, 217, 218, 219,
-	//	220, 221, 222, 223, 224, 225, 226, 227, 228, 229, 230, 231,
-	//	232, 233, 234, 235, 236, 237, 238, 239, 240, 241, 242, 243,
-	//	244, 245, 246, 247, 248, 249, 250, 251, 252, 253, 254, 255,
-	//}
-
-	st := 0
-
-	switch stripType {
-	case "rgbw":
-		st = ws281x.SK6812StripRGBW
-	case "rbgw":
-		st = ws281x.SK6812StripRBGW
-	case "grbw":
-		st = ws281x.SK6812StripGRBW
-	case "gbrw":
-		st = ws281x.SK6812StrioGBRW
-	case "brgw":
-		st = ws281x.SK6812StrioBRGW
-	case "bgrw":
-		st = ws281x.SK6812StripBGRW
-	case "rgb":
-		st = ws281x.WS2811StripRGB
-	case "rbg":
-		st = ws281x.WS2811StripRBG
-	case "grb":
-		st = ws281x.WS2811StripGRB
-	case "gbr":
-		st = ws281x.WS2811StripGBR
-	case "brg":
-		st = ws281x.WS2811StripBRG
-	case "bgr":
-		st = ws281x.WS2811StripBGR
-	default:
-		st = ws281x.WS2811StripBGR
+	opt.Channels = make([]ws281x.ChannelOption, len(channels))
+
+	ledCount := 0
+	leds := make([][]uint32, len(channels))
+
+	for i, ch := range channels {
+		opt.Channels[i] = ws281x.ChannelOption{
+			GpioPin:    ch.GpioPin,
+			LedCount:   ch.LedCount,
+			Brightness: ch.Brightness,
+			StripeType: stripType(ch.StripType),
+			// Gamma correction is applied by the caller before the colors
+			// reach the engine, so the library's default identity table is
+			// left in place.
+			Gamma: ws281x.DefaultOptions.Channels[0].Gamma,
+		}
+
+		leds[i] = make([]uint32, ch.LedCount)
+		ledCount += ch.LedCount
 	}
 
-	opt.Channels[0].StripeType = st
-
 	ws, err := ws281x.MakeWS2811(&opt)
 	if err != nil {
 		return nil, err
@@ -109,13 +86,44 @@ func Init(gpioPin int, ledCount int, brightness int, stripType string) (*Engine,
 	return &Engine{
 		LedsCount: ledCount,
 		engine:    ws,
-		leds:      make([]uint32, ledCount),
+		leds:      leds,
 		wg:        &wg,
 		stop:      stop,
 		rendering: false,
 	}, nil
 }
 
+func stripType(s string) int {
+	switch s {
+	case "rgbw":
+		return ws281x.SK6812StripRGBW
+	case "rbgw":
+		return ws281x.SK6812StripRBGW
+	case "grbw":
+		return ws281x.SK6812StripGRBW
+	case "gbrw":
+		return ws281x.SK6812StrioGBRW
+	case "brgw":
+		return ws281x.SK6812StrioBRGW
+	case "bgrw":
+		return ws281x.SK6812StripBGRW
+	case "rgb":
+		return ws281x.WS2811StripRGB
+	case "rbg":
+		return ws281x.WS2811StripRBG
+	case "grb":
+		return ws281x.WS2811StripGRB
+	case "gbr":
+		return ws281x.WS2811StripGBR
+	case "brg":
+		return ws281x.WS2811StripBRG
+	case "bgr":
+		return ws281x.WS2811StripBGR
+	default:
+		return ws281x.WS2811StripBGR
+	}
+}
+
 // Cancel returns the stop channel
 func (ws *Engine) Cancel() chan struct{} {
 	return ws.stop
@@ -145,37 +153,51 @@ func (ws *Engine) Fini() {
 // Clear resets all the leds (turns them off by setting their color to black)
 func (ws *Engine) Clear() error {
 	ws.mux.Lock()
-	ws.leds = make([]uint32, ws.LedsCount)
+	for i := range ws.leds {
+		ws.leds[i] = make([]uint32, len(ws.leds[i]))
+	}
 	ws.mux.Unlock()
 
 	return ws.Render()
 }
 
-// Render renders the colors saved on the leds array onto the led strip
+// Render renders the colors saved on the leds array onto the led strips
 func (ws *Engine) Render() error {
 	ws.mux.Lock()
 	leds := ws.leds
 	ws.mux.Unlock()
 
-	ws.engine.SetLedsSync(0, leds)
+	for i := range leds {
+		err := ws.engine.SetLedsSync(i, leds[i])
+		if err != nil {
+			return err
+		}
+	}
+
 	return ws.engine.Render()
 }
 
-// SetLedColor changes the color of the led in the specified index
+// SetLedColor changes the color of the led in the specified index. Indices
+// past the end of a channel continue on the next channel.
 func (ws *Engine) SetLedColor(index int, r uint8, g uint8, b uint8, a uint8) error {
 	// WRGB
 	color := uint32(a)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
 
 	ws.mux.Lock()
+	defer ws.mux.Unlock()
 
-	if index >= len(ws.leds) || index < 0 {
-		ws.mux.Unlock()
-
+	if index < 0 {
 		return errors.New("Invalid led index")
 	}
 
-	ws.leds[index] = color
-	ws.mux.Unlock()
+	for _, leds := range ws.leds {
+		if index < len(leds) {
+			leds[index] = color
+			return nil
+		}
+
+		index -= len(leds)
+	}
 
-	return nil
+	return errors.New("Invalid led index")
 }
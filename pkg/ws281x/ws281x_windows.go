@@ -42,6 +42,17 @@ func Init(_ int, ledsCount int, _ int, _ string) (*Engine, error) {
 	}, nil
 }
 
+// InitChannels placeholder function -- the strips of all channels are printed
+// as a single one
+func InitChannels(channels ...Channel) (*Engine, error) {
+	ledsCount := 0
+	for _, ch := range channels {
+		ledsCount += ch.LedCount
+	}
+
+	return Init(0, ledsCount, 0, "")
+}
+
 // Cancel returns the stop channel
 func (e *Engine) Cancel() chan struct{} {
 	return e.stop